@@ -0,0 +1,138 @@
+package envx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Действия, попадающие в журнал аудита
+const (
+	AuditSet = "set"
+	AuditDel = "del"
+)
+
+const (
+	auditMask = "***"
+	auditPkg  = "github.com/shestakovda/envx."
+)
+
+// AuditSecrets - части имен параметров, значения которых маскируются по-умолчанию
+var AuditSecrets = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "private"}
+
+// AuditRecord - запись журнала аудита об изменении параметра
+type AuditRecord struct {
+	Time   time.Time
+	Action string
+	Name   string
+	Old    string
+	New    string
+	Caller string
+}
+
+// AuditSink - получатель записей журнала аудита
+type AuditSink interface {
+	Audit(rec *AuditRecord)
+}
+
+// AuditFunc - функция как получатель записей журнала аудита
+type AuditFunc func(rec *AuditRecord)
+
+func (f AuditFunc) Audit(rec *AuditRecord) { f(rec) }
+
+// NewAuditHooks - обработчики, записывающие изменения параметров в журнал аудита
+// Значения параметров, в имени которых есть одна из частей secrets (или AuditSecrets, если не указаны), маскируются
+func NewAuditHooks(sink AuditSink, secrets ...string) Hooks {
+	if len(secrets) == 0 {
+		secrets = AuditSecrets
+	}
+
+	a := &audit{
+		sink:    sink,
+		secrets: make([]string, len(secrets)),
+	}
+
+	for i := range secrets {
+		a.secrets[i] = strings.ToLower(secrets[i])
+	}
+
+	return Hooks{
+		OnSet: func(name, old, value string) { a.write(AuditSet, name, old, value) },
+		OnDel: func(name, old string) { a.write(AuditDel, name, old, "") },
+	}
+}
+
+type audit struct {
+	sink    AuditSink
+	secrets []string
+}
+
+func (a *audit) write(action, name, old, value string) {
+	if a.secret(name) {
+		old, value = mask(old), mask(value)
+	}
+
+	a.sink.Audit(&AuditRecord{
+		Time:   time.Now(),
+		Action: action,
+		Name:   name,
+		Old:    old,
+		New:    value,
+		Caller: auditCaller(),
+	})
+}
+
+func (a *audit) secret(name string) bool {
+	name = strings.ToLower(name)
+
+	for i := range a.secrets {
+		if strings.Contains(name, a.secrets[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func mask(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	return auditMask
+}
+
+// auditCaller - первое место вызова за пределами пакета
+func auditCaller() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	for {
+		frame, more := frames.Next()
+
+		if !strings.HasPrefix(frame.Function, auditPkg) {
+			return fmt.Sprintf("%s:%d", path.Base(frame.File), frame.Line)
+		}
+
+		if !more {
+			return ""
+		}
+	}
+}
+
+// NewSlogAuditSink - запись журнала аудита в log/slog
+func NewSlogAuditSink(log *slog.Logger) AuditSink {
+	return AuditFunc(func(rec *AuditRecord) {
+		log.LogAttrs(context.Background(), slog.LevelInfo, "envx audit",
+			slog.String("action", rec.Action),
+			slog.String("name", rec.Name),
+			slog.String("old", rec.Old),
+			slog.String("new", rec.New),
+			slog.String("caller", rec.Caller),
+		)
+	})
+}
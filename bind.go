@@ -0,0 +1,460 @@
+package envx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/shestakovda/errx"
+)
+
+// Источники значений для BindRequest
+const (
+	SourceQuery  = "query"
+	SourceForm   = "form"
+	SourceJSON   = "json"
+	SourceHeader = "header"
+	SourceCookie = "cookie"
+	SourcePath   = "path"
+)
+
+const (
+	bindTag     = "envx"
+	bindDefault = "default"
+	bindSkip    = "-"
+	argField    = "Поле"
+)
+
+// BindBodyLimit - наибольший размер тела запроса для BindRequest по-умолчанию
+const BindBodyLimit = 10 << 20
+
+// BindOption - настройка BindRequest
+type BindOption func(o *bindOptions)
+
+type bindOptions struct {
+	limit int64
+	opts  []Option
+}
+
+// WithBodyLimit - наибольший размер тела запроса в байтах вместо BindBodyLimit
+func WithBodyLimit(limit int64) BindOption {
+	return func(o *bindOptions) {
+		o.limit = limit
+	}
+}
+
+// WithProviderOptions - настройки поставщиков, через которые читаются значения запроса
+//
+// Например, с WithExplicitEmpty явно пустое значение удовлетворяет опции required
+func WithProviderOptions(opts ...Option) BindOption {
+	return func(o *bindOptions) {
+		o.opts = append(o.opts, opts...)
+	}
+}
+
+// Вид тела запроса по заголовку `Content-Type`
+const (
+	bodyNone = iota
+	bodyJSON
+	bodyForm
+	bodyOther
+)
+
+var (
+	typeTime     = reflect.TypeOf(time.Time{})
+	typeDuration = reflect.TypeOf(time.Duration(0))
+	typeLocation = reflect.TypeOf((*time.Location)(nil))
+	typeStrings  = reflect.TypeOf([]string(nil))
+)
+
+// BindRequest - заполнение полей структуры значениями из HTTP-запроса
+//
+// Описание поля: `envx:"источник:имя[,опции]" default:"значение"`
+//
+// * Источники: query, form (тело формы), json (путь в JSON-теле), header, cookie, path (Go 1.22 ServeMux)
+// * Вид тела выбирается один раз по `Content-Type`: поле из формы при JSON-теле и наоборот - ErrHTTPInvalid
+// * Без `Content-Type` тело не читается, поля из формы и JSON считаются отсутствующими
+// * Тело больше BindBodyLimit (см. WithBodyLimit) - ErrHTTPInvalid
+// * Опции: required - значение обязательно и не пусто (см. WithProviderOptions), url, uuid, guid - проверка строки
+// * Поддерживаются string, bool, целые без знака, time.Duration, time.Time (RFC 3339), *time.Location и []string,
+// остальные типы разбираются как JSON
+// * Ошибки всех полей и ошибки чтения тела объединяются через errors.Join и подходят для WriteProblem
+// * Некорректное описание структуры - ErrBindInvalid, поля при этом не заполняются
+func BindRequest(r *http.Request, dst interface{}, opts ...BindOption) error {
+	val := reflect.ValueOf(dst)
+
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return ErrBindInvalid.WithDetail("Ожидается указатель на структуру, получено %T", dst)
+	}
+
+	fields, err := bindFields(val.Elem().Type())
+
+	if err != nil {
+		return err
+	}
+
+	src := &bindSources{
+		req:  r,
+		body: requestBody(r),
+		opt:  bindOptions{limit: BindBodyLimit},
+	}
+
+	for i := range opts {
+		opts[i](&src.opt)
+	}
+
+	errs := make([]error, 0, len(fields))
+	failed := make(map[string]bool, 2)
+
+	for i := range fields {
+		if err := src.accepts(fields[i]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		prv, err := src.provider(fields[i].source)
+
+		// Ошибка чтения тела попадает в отчет один раз, остальные поля все равно заполняются
+		if err != nil {
+			if !failed[fields[i].source] {
+				errs = append(errs, err)
+			}
+
+			failed[fields[i].source] = true
+			continue
+		}
+
+		if err = fields[i].bind(prv, val.Elem().Field(fields[i].index)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// requestBody - вид тела запроса по заголовку `Content-Type`
+func requestBody(r *http.Request) int {
+	typ := r.Header.Get("Content-Type")
+
+	if typ == "" {
+		return bodyNone
+	}
+
+	media, _, err := mime.ParseMediaType(typ)
+
+	switch {
+	case err != nil:
+		return bodyOther
+	case media == "application/json" || strings.HasSuffix(media, "+json"):
+		return bodyJSON
+	case media == "application/x-www-form-urlencoded" || media == "multipart/form-data":
+		return bodyForm
+	}
+
+	return bodyOther
+}
+
+type bindField struct {
+	index    int
+	source   string
+	name     string
+	format   string
+	def      string
+	hasDef   bool
+	required bool
+}
+
+func bindFields(typ reflect.Type) ([]bindField, error) {
+	fields := make([]bindField, 0, typ.NumField())
+
+	for i := 0; i < typ.NumField(); i++ {
+		tag, ok := typ.Field(i).Tag.Lookup(bindTag)
+
+		if !ok || tag == bindSkip {
+			continue
+		}
+
+		if typ.Field(i).PkgPath != "" {
+			return nil, ErrBindInvalid.WithDetail("Поле не экспортируется").WithDebug(errx.Debug{argField: typ.Field(i).Name})
+		}
+
+		opts := strings.Split(tag, ",")
+		source, name, ok := strings.Cut(opts[0], ":")
+
+		if !ok || name == "" {
+			return nil, ErrBindInvalid.WithDetail("Ожидается `источник:имя`").WithDebug(errx.Debug{argField: typ.Field(i).Name})
+		}
+
+		if !bindSource(source) {
+			return nil, ErrBindInvalid.WithDetail("Неизвестный источник `%s`", source).WithDebug(errx.Debug{argField: typ.Field(i).Name})
+		}
+
+		field := bindField{
+			index:  i,
+			source: source,
+			name:   name,
+		}
+
+		field.def, field.hasDef = typ.Field(i).Tag.Lookup(bindDefault)
+
+		for _, opt := range opts[1:] {
+			switch opt {
+			case "required":
+				field.required = true
+			case "url", "uuid", "guid":
+				field.format = opt
+			default:
+				return nil, ErrBindInvalid.WithDetail("Неизвестная опция `%s`", opt).WithDebug(errx.Debug{argField: typ.Field(i).Name})
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+func bindSource(source string) bool {
+	switch source {
+	case SourceQuery, SourceForm, SourceJSON, SourceHeader, SourceCookie, SourcePath:
+		return true
+	}
+
+	return false
+}
+
+func (f *bindField) key() string {
+	if f.source == SourceHeader {
+		return http.CanonicalHeaderKey(f.name)
+	}
+
+	return f.name
+}
+
+func (f *bindField) bind(prv *provider, dst reflect.Value) (err error) {
+	name := f.key()
+
+	// Пустое значение считается отсутствующим, если поставщик настроен без WithExplicitEmpty
+	// Значение по-умолчанию проходит ту же проверку, что и значение из запроса
+	if _, ok := prv.lookup(name); !ok {
+		if f.required {
+			return ErrRequired.WithDebug(errx.Debug{argName: name})
+		}
+
+		if !f.hasDef {
+			return nil
+		}
+
+		mem := NewMemDriver(1)
+		mem.Set(name, f.def)
+		prv = NewProvider(mem).(*provider)
+	}
+
+	var res interface{}
+
+	switch typ := dst.Type(); {
+	case typ == typeTime:
+		res, err = prv.TimeRFC3339(name, time.Time{})
+	case typ == typeDuration:
+		res, err = prv.Duration(name, 0)
+	case typ == typeLocation:
+		res, err = prv.Timezone(name, "")
+	case typ == typeStrings:
+		res, err = prv.StringArray(name, nil)
+	case typ.Kind() == reflect.Bool:
+		res, err = prv.BoolStrict(name, false)
+	case typ.Kind() == reflect.String:
+		res, err = f.string(prv, name)
+	case typ.Kind() >= reflect.Uint && typ.Kind() <= reflect.Uint64:
+		var num uint64
+
+		if num, err = prv.Uint64(name, 0); err == nil && dst.OverflowUint(num) {
+			err = ErrUint64Invalid.WithDetail("Значение больше допустимого для %s", typ).WithDebug(errx.Debug{argValue: num, argName: name})
+		}
+
+		res = num
+	default:
+		return prv.JSON(name, "", dst.Addr().Interface())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	dst.Set(reflect.ValueOf(res).Convert(dst.Type()))
+	return nil
+}
+
+func (f *bindField) string(prv Provider, name string) (string, error) {
+	switch f.format {
+	case "url":
+		return prv.URL(name, "")
+	case "uuid":
+		return prv.UUID(name, "")
+	case "guid":
+		return prv.GUID(name, "")
+	}
+
+	return prv.String(name, ""), nil
+}
+
+// bindSources - поставщики значений запроса, создаются при первом обращении
+type bindSources struct {
+	req   *http.Request
+	drv   Driver
+	body  int
+	opt   bindOptions
+	items map[string]*provider
+}
+
+// accepts - проверка, что поле ждет тело того вида, который указан в `Content-Type`
+func (s *bindSources) accepts(f bindField) error {
+	want := bodyJSON
+
+	switch f.source {
+	case SourceForm:
+		want = bodyForm
+	case SourceJSON:
+	default:
+		return nil
+	}
+
+	if s.body == bodyNone || s.body == want {
+		return nil
+	}
+
+	return ErrHTTPInvalid.WithDetail("Тело запроса не подходит для источника `%s`", f.source).WithDebug(errx.Debug{
+		argName:           f.key(),
+		"Тип содержимого": s.req.Header.Get("Content-Type"),
+	})
+}
+
+func (s *bindSources) provider(source string) (_ *provider, err error) {
+	if prv, ok := s.items[source]; ok {
+		return prv, nil
+	}
+
+	var drv Driver
+
+	switch source {
+	case SourceQuery:
+		drv = valuesDriver(s.req.URL.Query())
+	case SourceForm:
+		if drv, err = s.form(); err != nil {
+			return nil, err
+		}
+	case SourceJSON:
+		if drv, err = s.json(); err != nil {
+			return nil, err
+		}
+	case SourceHeader:
+		drv = &sourceDriver{drv: s.request(), prefix: HTTPHeader}
+	case SourceCookie:
+		drv = &sourceDriver{drv: s.request(), prefix: HTTPCookie}
+	case SourcePath:
+		drv = &sourceDriver{drv: s.request(), prefix: HTTPPath}
+	}
+
+	if s.items == nil {
+		s.items = make(map[string]*provider, 4)
+	}
+
+	s.items[source] = NewProvider(drv, s.opt.opts...).(*provider)
+	return s.items[source], nil
+}
+
+// form - значения формы, тело читается, только если это форма
+//
+// ParseForm не читает составную форму, а ParseMultipartForm скрывает ошибки ParseForm, поэтому нужны оба
+func (s *bindSources) form() (Driver, error) {
+	if s.body != bodyForm || s.req.Body == nil {
+		return NewMemDriver(0), nil
+	}
+
+	s.req.Body = http.MaxBytesReader(nil, s.req.Body, s.opt.limit)
+	err := s.req.ParseForm()
+
+	if err == nil {
+		if err = s.req.ParseMultipartForm(s.opt.limit); errors.Is(err, http.ErrNotMultipart) {
+			err = nil
+		}
+	}
+
+	if err != nil {
+		return nil, formError(s.req, err)
+	}
+
+	return valuesDriver(s.req.PostForm), nil
+}
+
+// json - JSON-тело запроса, после чтения тело снова доступно обработчику
+func (s *bindSources) json() (Driver, error) {
+	if s.body != bodyJSON || s.req.Body == nil {
+		return NewDriverJSONReadOnly(nil), nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(s.req.Body, s.opt.limit+1))
+
+	if err != nil {
+		return nil, ErrHTTPInvalid.WithReason(err)
+	}
+
+	if int64(len(body)) > s.opt.limit {
+		return nil, ErrHTTPInvalid.WithDetail("Слишком большое тело запроса")
+	}
+
+	s.req.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) > 0 && !json.Valid(body) {
+		return nil, ErrJSONInvalid.WithDetail("Тело запроса не является корректным JSON")
+	}
+
+	return NewDriverJSONReadOnly(body), nil
+}
+
+// request - драйвер заголовков, cookie и параметров пути без разбора формы
+func (s *bindSources) request() Driver {
+	if s.drv == nil {
+		s.drv = newRequestDriver(s.req, nil)
+	}
+
+	return s.drv
+}
+
+// valuesDriver - копия значений запроса, изменения не затрагивают сам запрос
+func valuesDriver(values map[string][]string) Driver {
+	mem := NewMemDriver(uint(len(values)))
+
+	for key := range values {
+		mem.SetArray(key, values[key])
+	}
+
+	return mem
+}
+
+// sourceDriver - значения одной части запроса, ключ указывается без префикса источника, только для чтения
+type sourceDriver struct {
+	drv    Driver
+	prefix string
+}
+
+func (d *sourceDriver) Set(name, value string) {}
+func (d *sourceDriver) Del(name string)        {}
+
+func (d *sourceDriver) Get(name string) string {
+	if list := d.GetArray(name); len(list) > 0 {
+		return list[0]
+	}
+
+	return ""
+}
+
+func (d *sourceDriver) GetArray(name string) []string {
+	return d.drv.GetArray(d.prefix + name)
+}
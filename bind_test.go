@@ -0,0 +1,236 @@
+package envx_test
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shestakovda/envx"
+	"github.com/stretchr/testify/assert"
+)
+
+type bindOrder struct {
+	ID      string         `envx:"path:id,uuid"`
+	Page    uint8          `envx:"query:page" default:"1"`
+	Tags    []string       `envx:"query:tag"`
+	Request string         `envx:"header:x-request-id,required"`
+	Session string         `envx:"cookie:session"`
+	User    uint64         `envx:"json:user.id,required"`
+	Items   []int          `envx:"json:items"`
+	Urgent  bool           `envx:"json:urgent"`
+	Timeout time.Duration  `envx:"query:timeout" default:"30s"`
+	Zone    *time.Location `envx:"query:tz"`
+	Ignored string         `envx:"-"`
+	Plain   string
+}
+
+func TestBindRequest(t *testing.T) {
+	var order bindOrder
+	var body string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		order = bindOrder{Plain: "keep"}
+
+		if err := envx.BindRequest(r, &order); err != nil {
+			envx.WriteProblem(w, r, err)
+			return
+		}
+
+		// Тело запроса остается доступным обработчику
+		raw, _ := io.ReadAll(r.Body)
+		body = string(raw)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	js := `{"user": {"id": 42}, "items": [1, 2], "urgent": true}`
+	req := httptest.NewRequest("POST", "/orders/0123456789ABCDEF0123456789ABCDEF?tag=a&tag=b&tz=Europe/Moscow", strings.NewReader(js))
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, js, body)
+	assert.Equal(t, "0123456789abcdef0123456789abcdef", order.ID)
+	assert.Equal(t, uint8(1), order.Page)
+	assert.Equal(t, []string{"a", "b"}, order.Tags)
+	assert.Equal(t, "req-1", order.Request)
+	assert.Equal(t, "s1", order.Session)
+	assert.Equal(t, uint64(42), order.User)
+	assert.Equal(t, []int{1, 2}, order.Items)
+	assert.True(t, order.Urgent)
+	assert.Equal(t, 30*time.Second, order.Timeout)
+	assert.Equal(t, "Europe/Moscow", order.Zone.String())
+	assert.Equal(t, "keep", order.Plain)
+
+	// Все ошибки собираются в один отчет
+	req = httptest.NewRequest("POST", "/orders/bad?page=300&timeout=soon", strings.NewReader("comment=ok"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var form struct {
+		Comment string `envx:"form:comment"`
+		Page    uint8  `envx:"query:page"`
+	}
+
+	err := envx.BindRequest(req, &form)
+	assert.Equal(t, "ok", form.Comment)
+	assert.True(t, errors.Is(err, envx.ErrUint64Invalid))
+
+	p := envx.NewProblem(envx.LangEN, envx.BindRequest(httptest.NewRequest("POST", "/?page=300&timeout=soon", nil), &order))
+	codes := make([]string, 0, len(p.Params))
+
+	for i := range p.Params {
+		codes = append(codes, p.Params[i].Code+":"+p.Params[i].Name)
+	}
+
+	assert.Equal(t, []string{"uint64_invalid:page", "required:X-Request-Id", "required:user.id", "duration_invalid:timeout"}, codes)
+
+	// Ошибки описания структуры
+	assert.True(t, errors.Is(envx.BindRequest(req, order), envx.ErrBindInvalid))
+	assert.True(t, errors.Is(envx.BindRequest(req, &struct {
+		A string `envx:"body:a"`
+	}{}), envx.ErrBindInvalid))
+	assert.True(t, errors.Is(envx.BindRequest(req, &struct {
+		A string `envx:"query:a,unknown"`
+	}{}), envx.ErrBindInvalid))
+
+	// Ошибка разбора тела не прерывает заполнение остальных полей
+	order = bindOrder{}
+	req = httptest.NewRequest("POST", "/?page=7", strings.NewReader("{broken"))
+	req.Header.Set("Content-Type", "application/json")
+	err = envx.BindRequest(req, &order)
+	assert.True(t, errors.Is(err, envx.ErrJSONInvalid))
+	assert.True(t, errors.Is(err, envx.ErrRequired))
+	assert.Equal(t, uint8(7), order.Page)
+
+	// Вид тела выбирается по `Content-Type`, а не по порядку полей
+	var mixed struct {
+		User    uint64 `envx:"json:user.id"`
+		Comment string `envx:"form:comment"`
+	}
+
+	req = httptest.NewRequest("POST", "/", strings.NewReader(`{"user": {"id": 42}}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	p = envx.NewProblem(envx.LangEN, envx.BindRequest(req, &mixed))
+	assert.Equal(t, uint64(42), mixed.User)
+	assert.Equal(t, []envx.ProblemParam{
+		{Code: "http_invalid", Name: "comment", Reason: "Invalid HTTP request (Request body does not match source `form`)"},
+	}, p.Params)
+
+	mixed.User = 0
+	req = httptest.NewRequest("POST", "/", strings.NewReader("comment=ok"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	err = envx.BindRequest(req, &mixed)
+	assert.True(t, errors.Is(err, envx.ErrHTTPInvalid))
+	assert.Equal(t, "ok", mixed.Comment)
+	assert.Equal(t, uint64(0), mixed.User)
+
+	// Без `Content-Type` тело не читается
+	mixed.Comment = ""
+	assert.NoError(t, envx.BindRequest(httptest.NewRequest("POST", "/", strings.NewReader("comment=ok")), &mixed))
+	assert.Equal(t, "", mixed.Comment)
+}
+
+func TestBindRequestLimit(t *testing.T) {
+	var dst struct {
+		Name string `envx:"json:name"`
+	}
+
+	var form struct {
+		Note string `envx:"form:note"`
+	}
+
+	js := `{"name": "` + strings.Repeat("a", 64) + `"}`
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(js))
+	req.Header.Set("Content-Type", "application/json")
+	p := envx.NewProblem(envx.LangEN, envx.BindRequest(req, &dst, envx.WithBodyLimit(32)))
+	assert.Equal(t, []envx.ProblemParam{
+		{Code: "http_invalid", Reason: "Invalid HTTP request (Request body is too large)"},
+	}, p.Params)
+
+	req = httptest.NewRequest("POST", "/", strings.NewReader(js))
+	req.Header.Set("Content-Type", "application/json")
+	assert.NoError(t, envx.BindRequest(req, &dst, envx.WithBodyLimit(int64(len(js)))))
+	assert.Len(t, dst.Name, 64)
+
+	req = httptest.NewRequest("POST", "/", strings.NewReader("note="+strings.Repeat("b", 64)))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	p = envx.NewProblem(envx.LangEN, envx.BindRequest(req, &form, envx.WithBodyLimit(32)))
+	assert.Equal(t, []envx.ProblemParam{
+		{Code: "http_invalid", Reason: "Invalid HTTP request (Request body is too large)"},
+	}, p.Params)
+}
+
+func TestBindRequestMultipart(t *testing.T) {
+	var body strings.Builder
+	var form struct {
+		Note string   `envx:"form:note"`
+		Tags []string `envx:"form:tag"`
+	}
+
+	mw := multipart.NewWriter(&body)
+	assert.NoError(t, mw.WriteField("note", " hello "))
+	assert.NoError(t, mw.WriteField("tag", "a"))
+	assert.NoError(t, mw.WriteField("tag", "b"))
+	file, err := mw.CreateFormFile("doc", "doc.txt")
+	assert.NoError(t, err)
+	_, err = io.WriteString(file, strings.Repeat("c", 64))
+	assert.NoError(t, err)
+	assert.NoError(t, mw.Close())
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body.String()))
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	assert.NoError(t, envx.BindRequest(req, &form))
+	assert.Equal(t, "hello", form.Note)
+	assert.Equal(t, []string{"a", "b"}, form.Tags)
+
+	// Предел размера действует и на составную форму
+	req = httptest.NewRequest("POST", "/", strings.NewReader(body.String()))
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	p := envx.NewProblem(envx.LangEN, envx.BindRequest(req, &form, envx.WithBodyLimit(64)))
+	assert.Equal(t, []envx.ProblemParam{
+		{Code: "http_invalid", Reason: "Invalid HTTP request (Request body is too large)"},
+	}, p.Params)
+
+	// Поврежденная составная форма
+	req = httptest.NewRequest("POST", "/", strings.NewReader("--x\r\nbroken"))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	p = envx.NewProblem(envx.LangEN, envx.BindRequest(req, &form))
+	assert.Equal(t, []envx.ProblemParam{
+		{Code: "http_invalid", Reason: "Invalid HTTP request (Request body is corrupted or malformed)"},
+	}, p.Params)
+}
+
+func TestBindRequestRequired(t *testing.T) {
+	var dst struct {
+		Name string `envx:"query:name,required"`
+		Page uint64 `envx:"query:page" default:"1"`
+	}
+
+	// Явно пустое значение не заменяет обязательное
+	req := httptest.NewRequest("GET", "/?name=&page=", nil)
+	err := envx.BindRequest(req, &dst)
+	assert.True(t, errors.Is(err, envx.ErrRequired))
+	assert.Equal(t, uint64(1), dst.Page)
+
+	req = httptest.NewRequest("GET", "/?name=%20", nil)
+	assert.True(t, errors.Is(envx.BindRequest(req, &dst), envx.ErrRequired))
+
+	// С WithExplicitEmpty пустое значение считается заданным
+	dst.Page = 5
+	req = httptest.NewRequest("GET", "/?name=&page=", nil)
+	assert.NoError(t, envx.BindRequest(req, &dst, envx.WithProviderOptions(envx.WithExplicitEmpty())))
+	assert.Equal(t, "", dst.Name)
+	assert.Equal(t, uint64(0), dst.Page)
+}
@@ -0,0 +1,90 @@
+package envx
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/shestakovda/errx"
+)
+
+// Единицы размера: SI - степени 1000, IEC - степени 1024
+// Эксабайты только полностью, `EB` или `EiB`, чтобы `1e3` не читалось как 1000 эксабайт
+var sizeUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"m":   1e6,
+	"mb":  1e6,
+	"g":   1e9,
+	"gb":  1e9,
+	"t":   1e12,
+	"tb":  1e12,
+	"p":   1e15,
+	"pb":  1e15,
+	"eb":  1e18,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+	"eib": 1 << 60,
+}
+
+// Единицы для FormatBytes, от больших к меньшим
+var sizeFormats = []struct {
+	unit string
+	size uint64
+}{
+	{"EiB", 1 << 60}, {"PiB", 1 << 50}, {"TiB", 1 << 40}, {"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10},
+	{"EB", 1e18}, {"PB", 1e15}, {"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3},
+}
+
+// FormatBytes - запись размера, которую Bytes разберет в то же число
+//
+// * Выбирается наибольшая единица IEC, затем SI, на которую размер делится без остатка: `512MiB`, `1500MB`
+// * Размер, не кратный ни одной единице, записывается числом
+func FormatBytes(size uint64) string {
+	for _, f := range sizeFormats {
+		if size >= f.size && size%f.size == 0 {
+			return strconv.FormatUint(size/f.size, 10) + f.unit
+		}
+	}
+
+	return strconv.FormatUint(size, 10)
+}
+
+// parseSize - разбор размера с единицей SI или IEC без учета регистра, результат должен быть целым числом байт
+func parseSize(s string) (uint64, errx.Error) {
+	pos := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+
+	if pos < 0 {
+		pos = len(s)
+	}
+
+	num, unit := s[:pos], strings.ToLower(strings.TrimSpace(s[pos:]))
+	mul, ok := sizeUnits[unit]
+
+	if !ok {
+		return 0, ErrBytesInvalid.WithDetail("Неизвестная единица `%s`", s[pos:])
+	}
+
+	val, ok := new(big.Rat).SetString(num)
+
+	if num == "" || strings.HasSuffix(num, ".") || !ok {
+		return 0, ErrBytesInvalid.WithDetail("Ожидается неотрицательное число перед единицей")
+	}
+
+	val.Mul(val, new(big.Rat).SetUint64(mul))
+
+	if !val.IsInt() {
+		return 0, ErrBytesInvalid.WithDetail("Размер должен быть целым числом байт")
+	}
+
+	if !val.Num().IsUint64() {
+		return 0, ErrBytesInvalid.WithDetail("Размер больше %d байт", uint64(1<<64-1))
+	}
+
+	return val.Num().Uint64(), nil
+}
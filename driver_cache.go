@@ -0,0 +1,217 @@
+package envx
+
+import (
+	"sync"
+	"time"
+)
+
+// cacheSweep - минимальный размер кеша, после которого при добавлении удаляются устаревшие значения
+const cacheSweep = 64
+
+// NewCachedDriver - кеширование результатов Get, GetArray и LookupValue другого драйвера на время ttl
+//
+// * Ключи приводятся к виду, принятому во внутреннем драйвере (например, верхний регистр для окружения)
+// * Чтение внутреннего драйвера идет без общей блокировки, одновременные промахи по ключу читают его один раз
+func NewCachedDriver(inner Driver, ttl time.Duration) CachedDriver {
+	return &cachedDriver{
+		ttl:   ttl,
+		inner: inner,
+		sweep: cacheSweep,
+		items: make(map[string]*cacheItem, 16),
+		calls: make(map[string]*cacheCall, 4),
+	}
+}
+
+type cachedDriver struct {
+	sync.RWMutex
+	ttl   time.Duration
+	inner Driver
+	sweep int
+	items map[string]*cacheItem
+	calls map[string]*cacheCall
+}
+
+// cacheItem - закешированное значение ключа, в т.ч. его отсутствие
+type cacheItem struct {
+	value   string
+	array   []string
+	typed   Value
+	found   bool
+	expires time.Time
+}
+
+// cacheCall - чтение ключа из внутреннего драйвера, которое ждут все одновременные промахи
+type cacheCall struct {
+	done  chan struct{}
+	item  *cacheItem
+	stale bool
+}
+
+func (d *cachedDriver) Get(name string) string {
+	return d.item(name).value
+}
+
+func (d *cachedDriver) GetArray(name string) []string {
+	arr := d.item(name).array
+
+	if arr == nil {
+		return nil
+	}
+
+	// Копия, чтобы вызывающий не мог испортить кеш
+	return append(make([]string, 0, len(arr)), arr...)
+}
+
+func (d *cachedDriver) Set(name, value string) {
+	d.inner.Set(name, value)
+	d.Invalidate(name)
+}
+
+func (d *cachedDriver) Del(name string) {
+	d.inner.Del(name)
+	d.Invalidate(name)
+}
+
+func (d *cachedDriver) Invalidate(names ...string) {
+	d.Lock()
+	defer d.Unlock()
+
+	if len(names) == 0 {
+		d.items = make(map[string]*cacheItem, len(d.items))
+
+		for key := range d.calls {
+			d.calls[key].stale = true
+		}
+
+		return
+	}
+
+	for i := range names {
+		key := normalizeKey(d.inner, names[i])
+		delete(d.items, key)
+
+		if call, ok := d.calls[key]; ok {
+			call.stale = true
+		}
+	}
+}
+
+func (d *cachedDriver) item(name string) *cacheItem {
+	now := time.Now()
+	key := normalizeKey(d.inner, name)
+
+	d.RLock()
+	item, ok := d.items[key]
+	d.RUnlock()
+
+	if ok && now.Before(item.expires) {
+		return item
+	}
+
+	d.Lock()
+
+	// Пока ждали блокировку, значение мог загрузить кто-то другой
+	if item, ok = d.items[key]; ok && now.Before(item.expires) {
+		d.Unlock()
+		return item
+	}
+
+	if call, ok := d.calls[key]; ok {
+		d.Unlock()
+		<-call.done
+
+		// Чтение, во время которого внутренний драйвер запаниковал, повторяется
+		if call.item == nil {
+			return d.item(name)
+		}
+
+		return call.item
+	}
+
+	call := &cacheCall{done: make(chan struct{})}
+	d.calls[key] = call
+	d.Unlock()
+
+	// Ожидающие освобождаются, даже если внутренний драйвер запаниковал
+	defer func() {
+		d.Lock()
+		defer d.Unlock()
+
+		delete(d.calls, key)
+		close(call.done)
+
+		// Значение, измененное во время чтения, не кешируется
+		if call.item != nil && !call.stale {
+			d.store(key, call.item, now)
+		}
+	}()
+
+	call.item = d.load(name, now)
+	return call.item
+}
+
+// load - чтение ключа из внутреннего драйвера без блокировки кеша
+func (d *cachedDriver) load(name string, now time.Time) *cacheItem {
+	item := &cacheItem{
+		value:   d.inner.Get(name),
+		array:   d.inner.GetArray(name),
+		expires: now.Add(d.ttl),
+	}
+
+	// Типизированное значение кешируется вместе со строковым, чтобы они не расходились
+	if typed := typedDriver(d.inner); typed != nil {
+		item.typed, item.found = typed.LookupValue(name)
+	}
+
+	return item
+}
+
+// store - сохранение значения с удалением устаревших, когда кеш разрастается
+func (d *cachedDriver) store(key string, item *cacheItem, now time.Time) {
+	if len(d.items) >= d.sweep {
+		for k := range d.items {
+			if !now.Before(d.items[k].expires) {
+				delete(d.items, k)
+			}
+		}
+
+		if d.sweep = 2 * len(d.items); d.sweep < cacheSweep {
+			d.sweep = cacheSweep
+		}
+	}
+
+	d.items[key] = item
+}
+
+func (d *cachedDriver) Keys(prefix string) []string {
+	if enum := enumDriver(d.inner); enum != nil {
+		return enum.Keys(prefix)
+	}
+
+	return nil
+}
+
+func (d *cachedDriver) Range(fn func(name string, values []string) bool) {
+	if enum := enumDriver(d.inner); enum != nil {
+		enum.Range(fn)
+	}
+}
+
+func (d *cachedDriver) LookupValue(name string) (Value, bool) {
+	item := d.item(name)
+	return item.typed, item.found
+}
+
+// normalizer - драйвер, приводящий ключи к единому виду, например к верхнему регистру
+type normalizer interface {
+	normalize(name string) string
+}
+
+// normalizeKey - ключ в том виде, в котором его хранит драйвер
+func normalizeKey(drv Driver, name string) string {
+	if norm, ok := drv.(normalizer); ok {
+		return norm.normalize(name)
+	}
+
+	return name
+}
@@ -0,0 +1,145 @@
+package envx_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shestakovda/envx"
+	"github.com/shestakovda/envx/envxtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type countDriver struct {
+	envx.Driver
+	gets int32
+	wait chan struct{}
+}
+
+func (d *countDriver) Get(name string) string {
+	if atomic.AddInt32(&d.gets, 1) == 1 && name == "boom" {
+		panic("boom")
+	}
+
+	if d.wait != nil && name == "slow" {
+		<-d.wait
+	}
+
+	return d.Driver.Get(name)
+}
+
+func TestCachedDriver(t *testing.T) {
+	testDriver(t, envx.NewCachedDriver(envx.NewMemDriver(16), time.Hour))
+
+	envxtest.DriverConformance(t, func(t *testing.T) envx.Driver {
+		return envx.NewCachedDriver(envx.NewMemDriver(16), time.Hour)
+	})
+
+	src := &countDriver{Driver: envx.NewMemDriver(16)}
+	drv := envx.NewCachedDriver(src, time.Hour)
+
+	// Отсутствие значения тоже кешируется
+	assert.Equal(t, e, drv.Get(k))
+	assert.Equal(t, e, drv.Get(k))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&src.gets))
+
+	// Изменение в обход кеша не видно до явного сброса
+	src.Set(k, v)
+	assert.Equal(t, e, drv.Get(k))
+	drv.Invalidate(k)
+	assert.Equal(t, tv, drv.Get(k))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&src.gets))
+
+	// Копия списка не портит кеш
+	arr := drv.GetArray(k)
+	arr[0] = wtf
+	assert.Equal(t, []string{tv}, drv.GetArray(k))
+
+	// Изменение через кеш сбрасывает его автоматически
+	drv.Set(k, wtf)
+	assert.Equal(t, []string{tv, wtf}, drv.GetArray(k))
+	drv.Del(k)
+	assert.Equal(t, e, drv.Get(k))
+
+	// Устаревшие значения перечитываются
+	drv = envx.NewCachedDriver(src, time.Millisecond)
+	assert.Equal(t, e, drv.Get(k))
+	src.Set(k, v)
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, tv, drv.Get(k))
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				drv.Set(k, v)
+				drv.Get(k)
+				drv.GetArray(k)
+				drv.Invalidate()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// Ключи приводятся к виду внутреннего драйвера
+	env := envx.NewCachedDriver(envx.NewEnvDriver("test_cache"), time.Hour)
+	assert.Equal(t, e, env.Get("a"))
+	env.Set("A", v)
+	assert.Equal(t, tv, env.Get("a"))
+	env.Del("a")
+	assert.Equal(t, e, env.Get("A"))
+
+	// Медленный промах не блокирует чтение других ключей, одновременные промахи читают ключ один раз
+	slow := &countDriver{Driver: envx.NewMemDriver(16), wait: make(chan struct{})}
+	slow.Set(k, v)
+	drv = envx.NewCachedDriver(slow, time.Hour)
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			drv.Get("slow")
+		}()
+	}
+
+	for atomic.LoadInt32(&slow.gets) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Equal(t, tv, drv.Get(k))
+	close(slow.wait)
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&slow.gets))
+
+	// Типизированные значения берутся из того же кеша, что и строковые
+	js := envx.NewDriverJSON([]byte(`{"port": 8080}`))
+	prv := envx.NewProvider(envx.NewCachedDriver(js, time.Hour))
+
+	num, err := prv.Uint64("port", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(8080), num)
+
+	assert.NoError(t, js.Update("port", "9090"))
+	num, err = prv.Uint64("port", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(8080), num)
+	assert.Equal(t, "8080", prv.Get("port"))
+
+	prv.Set("port", "7070")
+	num, err = prv.Uint64("port", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7070), num)
+
+	// Паника внутреннего драйвера не оставляет ключ заблокированным
+	boom := &countDriver{Driver: envx.NewMemDriver(16)}
+	boom.Set("boom", v)
+	drv = envx.NewCachedDriver(boom, time.Hour)
+
+	assert.Panics(t, func() { drv.Get("boom") })
+	assert.Equal(t, tv, drv.Get("boom"))
+}
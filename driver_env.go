@@ -36,3 +36,67 @@ func (d *envDriver) GetArray(name string) []string {
 
 	return nil
 }
+
+func (d *envDriver) Lookup(name string) (string, bool) {
+	val, ok := os.LookupEnv(d.pfx + strings.ToUpper(name))
+	return strings.TrimSpace(val), ok
+}
+
+func (d *envDriver) Snapshot(names ...string) map[string][]string {
+	snap := make(map[string][]string, len(names))
+
+	for _, name := range names {
+		if val, ok := os.LookupEnv(d.pfx + strings.ToUpper(name)); ok {
+			snap[name] = []string{val}
+		} else {
+			snap[name] = nil
+		}
+	}
+
+	return snap
+}
+
+// Restore - в окружении по ключу одно значение, поэтому возвращается первое из сохраненных
+func (d *envDriver) Restore(snap map[string][]string) {
+	for name, values := range snap {
+		if len(values) == 0 {
+			d.Del(name)
+		} else {
+			d.Set(name, values[0])
+		}
+	}
+}
+
+// Keys - ключи переменных окружения с префиксом драйвера, сам префикс отбрасывается
+func (d *envDriver) Keys(prefix string) []string {
+	return enumKeys(d.snapshot(), strings.ToUpper(prefix))
+}
+
+func (d *envDriver) Range(fn func(name string, values []string) bool) {
+	enumRange(d.snapshot(), fn)
+}
+
+func (d *envDriver) snapshot() map[string][]string {
+	return envItems(d.pfx, os.Environ())
+}
+
+// envItems - переменные с префиксом из списка вида KEY=VALUE, сам префикс отбрасывается
+func envItems(pfx string, env []string) map[string][]string {
+	items := make(map[string][]string, len(env))
+
+	for i := range env {
+		if !strings.HasPrefix(env[i], pfx) {
+			continue
+		}
+
+		if pair := strings.SplitN(env[i][len(pfx):], "=", 2); len(pair) == 2 && pair[0] != "" {
+			items[pair[0]] = []string{strings.TrimSpace(pair[1])}
+		}
+	}
+
+	return items
+}
+
+func (d *envDriver) normalize(name string) string {
+	return strings.ToUpper(name)
+}
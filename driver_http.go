@@ -3,64 +3,321 @@ package envx
 import (
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/shestakovda/errx"
+	"github.com/tidwall/sjson"
+)
+
+// Префиксы ключей для значений из других частей HTTP-запроса
+const (
+	HTTPHeader = "header:"
+	HTTPCookie = "cookie:"
+	HTTPPath   = "path:"
 )
 
 // NewHTTPDriver - получение аргументов из HTTP-запроса (в т.ч. из POST-формы)
+//
+// * Ключ с префиксом `header:` - заголовок, имя приводится к каноническому виду: `header:x-request-id`
+// * Ключ с префиксом `cookie:` - значения cookie с этим именем, только для чтения
+// * Ключ с префиксом `path:` - параметр пути из шаблона ServeMux (Go 1.22): `path:id`
+// * Ключи вида `user[name]`, `tags[]`, `items[0][id]` доступны как дерево по пути JSON-драйвера:
+// `user.name`, `tags`, `items.#.id`, а Provider.JSON разбирает вложенные объекты и списки (значения - строки)
+// * Keys и Range перечисляют только параметры формы
+// * Драйвер работает с копией запроса: Set и Del не меняют сам запрос
 func NewHTTPDriver(req *http.Request) (_ Driver, err error) {
 	if err = req.ParseForm(); err != nil {
-		var detail string
-
-		switch msg := err.Error(); {
-		case strings.Contains(msg, "invalid URL escape"):
-			detail = "Некорректное URL-кодирование"
-		case strings.Contains(msg, "missing form body"):
-			detail = "Отсутствует тело запроса"
-		case strings.Contains(msg, "too large"):
-			detail = "Слишком большое тело запроса"
-		case strings.Contains(msg, "mime"):
-			detail = "Некорректное содержимое заголовка `Content-Type`"
-		default:
-			detail = "Тело запроса повреждено или сформировано некорректно"
-		}
+		return nil, formError(req, err)
+	}
+
+	return newRequestDriver(req, req.Form), nil
+}
+
+// formError - ошибка разбора формы с понятной детализацией вместо текста net/http
+func formError(req *http.Request, err error) errx.Error {
+	var detail string
+
+	switch msg := err.Error(); {
+	case strings.Contains(msg, "invalid URL escape"):
+		detail = "Некорректное URL-кодирование"
+	case strings.Contains(msg, "missing form body"):
+		detail = "Отсутствует тело запроса"
+	case strings.Contains(msg, "too large"):
+		detail = "Слишком большое тело запроса"
+	case strings.Contains(msg, "mime"):
+		detail = "Некорректное содержимое заголовка `Content-Type`"
+	default:
+		detail = "Тело запроса повреждено или сформировано некорректно"
+	}
+
+	return ErrHTTPInvalid.WithDetail(detail).WithDebug(errx.Debug{
+		"Адрес":     req.URL.RawPath,
+		"Заголовки": req.Header,
+	})
+}
 
-		return nil, ErrHTTPInvalid.WithDetail(detail).WithDebug(errx.Debug{
-			"Адрес":     req.URL.RawPath,
-			"Заголовки": req.Header,
-		})
+// newRequestDriver - драйвер над копией частей запроса, сам запрос не изменяется
+func newRequestDriver(req *http.Request, form url.Values) *httpDriver {
+	d := &httpDriver{
+		params:  req.Clone(req.Context()),
+		values:  make(url.Values, len(form)),
+		header:  req.Header.Clone(),
+		cookies: make(map[string][]string, 4),
+		path:    make(map[string]string, 4),
 	}
 
-	return &httpDriver{
-		values: req.Form,
-	}, nil
+	for key := range form {
+		d.values[key] = trimValues(form[key])
+	}
+
+	for _, cookie := range req.Cookies() {
+		d.cookies[cookie.Name] = append(d.cookies[cookie.Name], strings.TrimSpace(cookie.Value))
+	}
+
+	if d.header == nil {
+		d.header = make(http.Header)
+	}
+
+	d.tree = formTree(d.values)
+	return d
 }
 
+// httpDriver - все части запроса скопированы при создании, поэтому драйвер можно читать
+// одновременно с изменением исходного запроса
 type httpDriver struct {
-	values url.Values
+	sync.RWMutex
+	params  *http.Request // копия только ради PathValue: в Go 1.22 параметры пути нельзя перечислить, а Clone их копирует
+	values  url.Values
+	header  http.Header
+	cookies map[string][]string
+	path    map[string]string
+	tree    JSONDriver
 }
 
 func (d *httpDriver) Set(name, value string) {
-	d.values.Set(name, value)
+	d.Lock()
+	defer d.Unlock()
+
+	switch source, key := splitSource(name); source {
+	case HTTPHeader:
+		d.header.Set(key, strings.TrimSpace(value))
+	case HTTPPath:
+		d.path[key] = strings.TrimSpace(value)
+	case HTTPCookie:
+	default:
+		d.values.Set(name, strings.TrimSpace(value))
+		d.touch(name)
+	}
 }
 
 func (d *httpDriver) Get(name string) string {
-	return strings.TrimSpace(d.values.Get(name))
+	if list := d.GetArray(name); len(list) > 0 {
+		return list[0]
+	}
+
+	return ""
 }
 
 func (d *httpDriver) GetArray(name string) []string {
-	if _, ok := d.values[name]; !ok {
+	d.RLock()
+	defer d.RUnlock()
+
+	switch source, key := splitSource(name); source {
+	case HTTPHeader:
+		if values := d.header.Values(key); len(values) > 0 {
+			return trimValues(values)
+		}
+
+		return nil
+	case HTTPCookie:
+		if values, ok := d.cookies[key]; ok {
+			return append(make([]string, 0, len(values)), values...)
+		}
+
+		return nil
+	case HTTPPath:
+		value, ok := d.path[key]
+
+		if !ok {
+			value = strings.TrimSpace(d.params.PathValue(key))
+		}
+
+		if value != "" {
+			return []string{value}
+		}
+
 		return nil
 	}
 
-	for i := range d.values[name] {
-		d.values[name][i] = strings.TrimSpace(d.values[name][i])
+	if values, ok := d.values[name]; ok {
+		return append(make([]string, 0, len(values)), values...)
+	}
+
+	if d.tree != nil {
+		return d.tree.GetArray(name)
 	}
 
-	return d.values[name]
+	return nil
 }
 
 func (d *httpDriver) Del(name string) {
-	d.values.Del(name)
+	d.Lock()
+	defer d.Unlock()
+
+	switch source, key := splitSource(name); source {
+	case HTTPHeader:
+		d.header.Del(key)
+	case HTTPPath:
+		d.path[key] = ""
+	case HTTPCookie:
+	default:
+		d.values.Del(name)
+		d.touch(name)
+	}
+}
+
+// touch - перестроение дерева после изменения ключа со скобками
+func (d *httpDriver) touch(name string) {
+	if strings.IndexByte(name, '[') > 0 {
+		d.tree = formTree(d.values)
+	}
+}
+
+// LookupValue - значение из дерева ключей со скобками, если такого ключа нет в самой форме
+func (d *httpDriver) LookupValue(name string) (Value, bool) {
+	d.RLock()
+	defer d.RUnlock()
+
+	if _, ok := d.values[name]; ok || d.tree == nil {
+		return Value{}, false
+	}
+
+	return d.tree.LookupValue(name)
+}
+
+func (d *httpDriver) Keys(prefix string) []string {
+	return enumKeys(d.snapshot(), prefix)
+}
+
+func (d *httpDriver) Range(fn func(name string, values []string) bool) {
+	enumRange(d.snapshot(), fn)
+}
+
+func (d *httpDriver) snapshot() map[string][]string {
+	d.RLock()
+	defer d.RUnlock()
+
+	items := make(map[string][]string, len(d.values))
+
+	for key := range d.values {
+		items[key] = trimValues(d.values[key])
+	}
+
+	return items
+}
+
+// formTree - JSON-документ из ключей формы со скобками, nil - таких ключей нет
+//
+// * Ключи разбираются в порядке сортировки, некорректные пропускаются
+// * `[]` допускается только в конце и добавляет значения в список
+// * Несколько значений у ключа без `[]` образуют список
+// * Индекс не меньше числа значений у всех ключей со скобками пропускается: такой массив
+// состоял бы в основном из пустых элементов, а `items[3000000][id]` - из трех миллионов
+func formTree(values url.Values) JSONDriver {
+	var err error
+	var doc []byte
+	var count int
+
+	keys := make([]string, 0, 8)
+
+	for key := range values {
+		if strings.IndexByte(key, '[') > 0 {
+			keys = append(keys, key)
+			count += len(values[key])
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var res []byte
+
+		path, add, ok := bracketPath(key, count)
+
+		if !ok {
+			continue
+		}
+
+		list := trimValues(values[key])
+
+		switch {
+		case add:
+			res = doc
+
+			for i := 0; i < len(list) && err == nil; i++ {
+				res, err = sjson.SetBytes(res, path+arrSuffix, list[i])
+			}
+		case len(list) == 1:
+			res, err = sjson.SetBytes(doc, path, list[0])
+		default:
+			res, err = sjson.SetBytes(doc, path, list)
+		}
+
+		// Ключ, противоречащий уже разобранным, пропускается целиком
+		if err == nil {
+			doc = res
+		}
+	}
+
+	return NewDriverJSONReadOnly(doc)
+}
+
+// bracketPath - путь JSON-драйвера из ключа со скобками, add - ключ заканчивается на `[]`
+// Числовой сегмент допускается, только если он меньше limit
+func bracketPath(key string, limit int) (path string, add, ok bool) {
+	pos := strings.IndexByte(key, '[')
+	segs := []string{escapeKey(key[:pos])}
+
+	for rest := key[pos:]; rest != ""; {
+		end := strings.IndexByte(rest, ']')
+
+		if rest[0] != '[' || end < 0 || add {
+			return "", false, false
+		}
+
+		switch seg := rest[1:end]; {
+		case seg == "":
+			add = true
+		case strings.Trim(seg, "0123456789") == "":
+			if num, err := strconv.Atoi(seg); err != nil || num >= limit {
+				return "", false, false
+			}
+
+			segs = append(segs, seg)
+		default:
+			segs = append(segs, escapeKey(seg))
+		}
+
+		rest = rest[end+1:]
+	}
+
+	return strings.Join(segs, "."), add, true
+}
+
+// splitSource - префикс источника и имя значения
+func splitSource(name string) (source, key string) {
+	for _, source = range [...]string{HTTPHeader, HTTPCookie, HTTPPath} {
+		if strings.HasPrefix(name, source) {
+			return source, strings.TrimSpace(name[len(source):])
+		}
+	}
+
+	return "", name
 }
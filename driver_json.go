@@ -1,79 +1,279 @@
 package envx
 
 import (
+	"strconv"
 	"strings"
+	"sync"
+	"unsafe"
 
 	"github.com/shestakovda/errx"
 	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 )
 
 const (
-	objDelim     = ".$."
-	objSuffix    = ".$"
-	keySuffix    = ".@"
-	jsonReadOnly = "json args driver is read-only"
+	arrSuffix   = ".-1"
+	pathSpecial = `\.*?|#$@!`
 )
 
-func NewDriverJSON(js []byte) Driver {
+// NewDriverJSON - получение аргументов из JSON-документа с возможностью изменения
+func NewDriverJSON(js []byte) JSONDriver {
 	return &jsonDriver{
 		src: js,
 	}
 }
 
+// NewDriverJSONReadOnly - получение аргументов из JSON-документа только для чтения
+func NewDriverJSONReadOnly(js []byte) JSONDriver {
+	return &jsonDriver{
+		src: js,
+		ro:  true,
+	}
+}
+
 type jsonDriver struct {
+	sync.RWMutex
+	ro  bool
 	src []byte
 }
 
-func (d *jsonDriver) Set(name, value string) { panic(errx.New(jsonReadOnly)) }
-func (d *jsonDriver) Del(name string)        { panic(errx.New(jsonReadOnly)) }
+func (d *jsonDriver) Set(name, value string) { _ = d.Update(name, value) }
+func (d *jsonDriver) Del(name string)        { _ = d.Remove(name) }
+
+func (d *jsonDriver) Update(name, value string) (err error) {
+	if d.ro {
+		return ErrReadOnly.WithDebug(errx.Debug{argName: name})
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	path := name
+	value = strings.TrimSpace(value)
+
+	// Если по ключу уже список, значение добавляется в конец
+	if gjson.GetBytes(d.src, name).IsArray() {
+		path += arrSuffix
+	}
+
+	if d.src, err = sjson.SetBytes(d.document(), path, value); err != nil {
+		return ErrPathInvalid.WithReason(err).WithDebug(errx.Debug{argName: name})
+	}
+
+	return nil
+}
+
+func (d *jsonDriver) Remove(name string) (err error) {
+	if d.ro {
+		return ErrReadOnly.WithDebug(errx.Debug{argName: name})
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if d.src, err = sjson.DeleteBytes(d.document(), name); err != nil {
+		return ErrPathInvalid.WithReason(err).WithDebug(errx.Debug{argName: name})
+	}
+
+	return nil
+}
+
+func (d *jsonDriver) Bytes() []byte {
+	d.RLock()
+	defer d.RUnlock()
+
+	return append(make([]byte, 0, len(d.src)), d.src...)
+}
+
+// document - исходный документ или пустой объект, если документа нет
+func (d *jsonDriver) document() []byte {
+	if len(d.src) == 0 {
+		return []byte("{}")
+	}
+
+	return d.src
+}
 
 func (d *jsonDriver) Get(name string) string {
-	return gjson.GetBytes(d.src, name).String()
+	res, _ := d.lookup(name)
+	return res.String()
 }
 
-func (d *jsonDriver) GetArray(name string) []string {
-	var collect func(items gjson.Result)
+func (d *jsonDriver) LookupValue(name string) (Value, bool) {
+	res, ok := d.lookup(name)
 
-	list := make([]string, 0, 16)
+	if !ok {
+		return Value{}, false
+	}
 
-	collect = func(res gjson.Result) {
-		if !res.Exists() {
-			return
-		}
+	return newValue(res), true
+}
+
+// lookup - значение по пути: точный путь ищется через gjson, путь с шаблонами дает первое найденное
+// Найденное значение копируется, чтобы не зависеть от последующих изменений документа
+func (d *jsonDriver) lookup(name string) (gjson.Result, bool) {
+	path, err := parsePath(name)
+
+	if err != nil {
+		return gjson.Result{}, false
+	}
+
+	d.RLock()
+	defer d.RUnlock()
+
+	if key, ok := path.exact(); ok {
+		res := gjson.GetBytes(d.src, key)
+		return res, res.Exists()
+	}
+
+	res, ok := path.first(parseBytes(d.src))
+
+	if !ok {
+		return gjson.Result{}, false
+	}
+
+	return gjson.Parse(strings.Clone(res.Raw)), true
+}
 
-		if !res.IsArray() {
-			list = append(list, res.String())
+func (d *jsonDriver) Sub(name string) Driver {
+	d.RLock()
+	defer d.RUnlock()
+
+	return d.sub(gjson.GetBytes(d.src, name))
+}
+
+func (d *jsonDriver) Each(name string, fn func(i int, sub Driver) bool) {
+	var subs []Driver
+
+	// Драйверы собираются заранее, чтобы fn могла вызывать методы исходного драйвера
+	d.RLock()
+	gjson.GetBytes(d.src, name).ForEach(func(key, value gjson.Result) bool {
+		subs = append(subs, d.sub(value))
+		return true
+	})
+	d.RUnlock()
+
+	for i := range subs {
+		if !fn(i, subs[i]) {
 			return
 		}
+	}
+}
+
+// sub - драйвер над копией поддерева, режим только для чтения наследуется
+func (d *jsonDriver) sub(res gjson.Result) Driver {
+	var src []byte
+
+	if res.IsObject() || res.IsArray() {
+		src = []byte(res.Raw)
+	}
 
-		items := res.Array()
-		for i := range items {
-			collect(items[i])
+	return &jsonDriver{
+		src: src,
+		ro:  d.ro,
+	}
+}
+
+func (d *jsonDriver) GetArray(name string) []string {
+	list, _ := d.Select(name)
+	return list
+}
+
+func (d *jsonDriver) Select(name string) (_ []string, err error) {
+	var path jsonPath
+
+	if path, err = parsePath(name); err != nil {
+		return nil, err
+	}
+
+	d.RLock()
+	defer d.RUnlock()
+
+	return path.eval(parseBytes(d.src), nil), nil
+}
+
+// parseBytes - разбор документа без копирования, найденные значения копируются при сборе
+func parseBytes(src []byte) gjson.Result {
+	if len(src) == 0 {
+		return gjson.Result{}
+	}
+
+	return gjson.Parse(unsafe.String(&src[0], len(src)))
+}
+
+// Keys - пути ко всем листьям документа, массивы простых значений считаются одним листом
+func (d *jsonDriver) Keys(prefix string) []string {
+	return enumKeys(d.snapshot(), prefix)
+}
+
+func (d *jsonDriver) Range(fn func(name string, values []string) bool) {
+	enumRange(d.snapshot(), fn)
+}
+
+func (d *jsonDriver) snapshot() map[string][]string {
+	d.RLock()
+	defer d.RUnlock()
+
+	var walk func(path string, res gjson.Result)
+
+	items := make(map[string][]string, 16)
+
+	walk = func(path string, res gjson.Result) {
+		switch {
+		case res.IsObject():
+			res.ForEach(func(key, value gjson.Result) bool {
+				walk(joinPath(path, escapeKey(key.String())), value)
+				return true
+			})
+		case res.IsArray():
+			list := res.Array()
+			vals := make([]string, 0, len(list))
+
+			for i := range list {
+				if list[i].IsObject() || list[i].IsArray() {
+					for j := range list {
+						walk(joinPath(path, strconv.Itoa(j)), list[j])
+					}
+					return
+				}
+
+				vals = append(vals, list[i].String())
+			}
+
+			if path != "" {
+				items[path] = vals
+			}
+		case path != "":
+			items[path] = []string{res.String()}
 		}
 	}
 
-	if strings.HasSuffix(name, objSuffix) {
-		gjson.GetBytes(d.src, strings.TrimSuffix(name, objSuffix)).ForEach(func(key, value gjson.Result) bool {
-			collect(value)
-			return true
-		})
+	walk("", gjson.ParseBytes(d.src))
+	return items
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
 	}
 
-	if strings.HasSuffix(name, keySuffix) {
-		gjson.GetBytes(d.src, strings.TrimSuffix(name, keySuffix)).ForEach(func(key, value gjson.Result) bool {
-			collect(key)
-			return true
-		})
+	return path + "." + key
+}
+
+// escapeKey - экранирование спецсимволов пути в имени ключа
+func escapeKey(key string) string {
+	if !strings.ContainsAny(key, pathSpecial) {
+		return key
 	}
 
-	if parts := strings.Split(name, objDelim); len(parts) > 1 {
-		gjson.GetBytes(d.src, parts[0]).ForEach(func(key, value gjson.Result) bool {
-			collect(value.Get(parts[1]))
-			return true
-		})
-	} else {
-		collect(gjson.GetBytes(d.src, name))
+	var buf strings.Builder
+
+	for _, r := range key {
+		if strings.ContainsRune(pathSpecial, r) {
+			buf.WriteByte('\\')
+		}
+		buf.WriteRune(r)
 	}
 
-	return list
+	return buf.String()
 }
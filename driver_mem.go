@@ -5,45 +5,125 @@ import (
 	"sync"
 )
 
-func NewMemDriver(size uint) Driver {
+// NewMemDriver - хранение аргументов в памяти, по ключу может быть несколько значений
+func NewMemDriver(size uint) MemDriver {
 	return &memDriver{
-		data: make(map[string]string, int(size)),
+		data: make(map[string][]string, int(size)),
 	}
 }
 
 type memDriver struct {
 	sync.RWMutex
-	data map[string]string
+	data map[string][]string
 }
 
 func (d *memDriver) Set(name, value string) {
 	d.Lock()
 	defer d.Unlock()
-	d.data[name] = value
+	d.data[name] = append(d.data[name], value)
+}
+
+func (d *memDriver) Replace(name, value string) {
+	d.Lock()
+	defer d.Unlock()
+	d.data[name] = []string{value}
+}
+
+func (d *memDriver) SetArray(name string, values []string) {
+	d.Lock()
+	defer d.Unlock()
+
+	if values == nil {
+		delete(d.data, name)
+		return
+	}
+
+	d.data[name] = append(make([]string, 0, len(values)), values...)
+}
+
+func (d *memDriver) Snapshot(names ...string) map[string][]string {
+	d.RLock()
+	defer d.RUnlock()
+
+	snap := make(map[string][]string, len(names))
+
+	for _, name := range names {
+		if vals, ok := d.data[name]; ok {
+			snap[name] = append(make([]string, 0, len(vals)), vals...)
+		} else {
+			snap[name] = nil
+		}
+	}
+
+	return snap
+}
+
+func (d *memDriver) Restore(snap map[string][]string) {
+	for name, values := range snap {
+		d.SetArray(name, values)
+	}
 }
 
 func (d *memDriver) Get(name string) string {
 	d.RLock()
 	defer d.RUnlock()
 
-	return strings.TrimSpace(d.data[name])
+	if vals := d.data[name]; len(vals) > 0 {
+		return strings.TrimSpace(vals[0])
+	}
+
+	return ""
 }
 
 func (d *memDriver) GetArray(name string) []string {
 	d.RLock()
 	defer d.RUnlock()
 
-	val, ok := d.data[name]
-
-	if ok {
-		return []string{strings.TrimSpace(val)}
+	if vals, ok := d.data[name]; ok {
+		return trimValues(vals)
 	}
 
 	return nil
 }
 
+func (d *memDriver) Lookup(name string) (string, bool) {
+	d.RLock()
+	defer d.RUnlock()
+
+	if vals, ok := d.data[name]; ok {
+		if len(vals) > 0 {
+			return strings.TrimSpace(vals[0]), true
+		}
+
+		return "", true
+	}
+
+	return "", false
+}
+
 func (d *memDriver) Del(name string) {
 	d.Lock()
 	defer d.Unlock()
 	delete(d.data, name)
 }
+
+func (d *memDriver) Keys(prefix string) []string {
+	return enumKeys(d.snapshot(), prefix)
+}
+
+func (d *memDriver) Range(fn func(name string, values []string) bool) {
+	enumRange(d.snapshot(), fn)
+}
+
+func (d *memDriver) snapshot() map[string][]string {
+	d.RLock()
+	defer d.RUnlock()
+
+	items := make(map[string][]string, len(d.data))
+
+	for key := range d.data {
+		items[key] = trimValues(d.data[key])
+	}
+
+	return items
+}
@@ -0,0 +1,448 @@
+package envx
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/shestakovda/errx"
+)
+
+var rxPropIndex = regexp.MustCompile(`\[(\d+)\]`)
+
+// NewDriverProperties - получение аргументов из файла в формате Java .properties
+//
+// * Поддерживаются комментарии `#` и `!`, разделители `=`, `:` и пробел, продолжение строки через `\`
+// * Поддерживаются экранирование `\t`, `\n`, `\r`, `\f` и `\uXXXX`
+// * Индексы в квадратных скобках приводятся к синтаксису JSON-драйвера: `servers[0].host` - `servers.0.host`
+// * Get и GetArray понимают тот же синтаксис пути, что и JSON-драйвер: `servers.#.host`, `db.$.port`, `**.port`, `db.@`
+// * Пронумерованные ключи `tags.0`, `tags.1` - список `tags`, Set добавляет в него значение со следующим номером
+func NewDriverProperties(src []byte) (_ Driver, err error) {
+	d := &propsDriver{
+		data: make(map[string]string, 16),
+		keys: make([]propEntry, 0, 16),
+	}
+
+	if err = d.parse(string(src)); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+type propsDriver struct {
+	sync.RWMutex
+	data map[string]string
+	keys []propEntry // ключи в порядке propLess, поддерживается при каждом изменении
+}
+
+// propEntry - ключ, заранее разбитый на сегменты
+type propEntry struct {
+	key   string
+	parts []string
+}
+
+func (d *propsDriver) Set(name, value string) {
+	key := propKey(name)
+
+	d.Lock()
+	defer d.Unlock()
+
+	// Если по ключу уже список, значение добавляется в конец
+	if next := d.next(key); next > 0 {
+		key += "." + strconv.Itoa(next)
+	}
+
+	d.put(key, value)
+}
+
+func (d *propsDriver) Get(name string) string {
+	if list := d.GetArray(name); len(list) > 0 {
+		return list[0]
+	}
+
+	return ""
+}
+
+func (d *propsDriver) Del(name string) {
+	key := propKey(name)
+
+	d.Lock()
+	defer d.Unlock()
+
+	d.drop(key)
+
+	// Вместе с ключом удаляется и список с тем же именем
+	for _, item := range d.items(key) {
+		d.drop(item)
+	}
+}
+
+func (d *propsDriver) GetArray(name string) []string {
+	path, err := parsePath(propKey(name))
+
+	if err != nil {
+		return nil
+	}
+
+	d.RLock()
+	defer d.RUnlock()
+
+	list := d.match(path)
+
+	// Если самого ключа нет, но есть пронумерованные вложенные, это список
+	if list == nil && path[len(path)-1].kind == kindKey {
+		list = d.values(append(path[:len(path):len(path)], segment{kind: kindAll}))
+	}
+
+	return list
+}
+
+func (d *propsDriver) normalize(name string) string {
+	return propKey(name)
+}
+
+func (d *propsDriver) Keys(prefix string) []string {
+	return enumKeys(d.snapshot(), prefix)
+}
+
+func (d *propsDriver) Range(fn func(name string, values []string) bool) {
+	enumRange(d.snapshot(), fn)
+}
+
+func (d *propsDriver) snapshot() map[string][]string {
+	d.RLock()
+	defer d.RUnlock()
+
+	items := make(map[string][]string, len(d.data))
+
+	for key := range d.data {
+		items[key] = []string{strings.TrimSpace(d.data[key])}
+	}
+
+	return items
+}
+
+// put - установка значения с сохранением порядка ключей
+func (d *propsDriver) put(key, value string) {
+	if _, ok := d.data[key]; !ok {
+		entry := propEntry{key: key, parts: strings.Split(key, ".")}
+		pos := sort.Search(len(d.keys), func(i int) bool { return !propLess(d.keys[i].parts, entry.parts) })
+
+		d.keys = append(d.keys, propEntry{})
+		copy(d.keys[pos+1:], d.keys[pos:])
+		d.keys[pos] = entry
+	}
+
+	d.data[key] = value
+}
+
+// drop - удаление значения вместе с его местом в порядке ключей
+func (d *propsDriver) drop(key string) {
+	if _, ok := d.data[key]; !ok {
+		return
+	}
+
+	parts := strings.Split(key, ".")
+	pos := sort.Search(len(d.keys), func(i int) bool { return !propLess(d.keys[i].parts, parts) })
+
+	d.keys = append(d.keys[:pos], d.keys[pos+1:]...)
+	delete(d.data, key)
+}
+
+// items - ключи элементов списка `key.0`, `key.1`... в порядке номеров
+func (d *propsDriver) items(key string) []string {
+	var list []string
+
+	for _, entry := range d.keys {
+		if len(entry.parts) > 1 && entry.key == key+"."+entry.parts[len(entry.parts)-1] && propIndex(entry.parts[len(entry.parts)-1]) {
+			list = append(list, entry.key)
+		}
+	}
+
+	return list
+}
+
+// next - номер для нового элемента списка, 0 - по ключу нет списка
+func (d *propsDriver) next(key string) int {
+	var next int
+
+	for _, item := range d.items(key) {
+		num, _ := strconv.Atoi(item[len(key)+1:])
+
+		if num >= next {
+			next = num + 1
+		}
+	}
+
+	return next
+}
+
+// match - значения по пути, `@` и `#` в конце - имена и количество следующих сегментов
+func (d *propsDriver) match(path jsonPath) []string {
+	switch head := path[:len(path)-1]; path[len(path)-1].kind {
+	case kindKeys:
+		return d.names(head, false)
+	case kindAll:
+		if names := d.names(head, true); len(names) > 0 {
+			return []string{strconv.Itoa(len(names))}
+		}
+
+		return nil
+	}
+
+	return d.values(path)
+}
+
+// values - значения всех ключей, подходящих под путь, в порядке следования индексов
+func (d *propsDriver) values(path jsonPath) []string {
+	var list []string
+
+	for _, entry := range d.keys {
+		if propMatch(path, entry.parts) {
+			list = append(list, strings.TrimSpace(d.data[entry.key]))
+		}
+	}
+
+	return list
+}
+
+// names - уникальные имена сегментов, следующих за путем, index - только числовые
+func (d *propsDriver) names(path jsonPath, index bool) []string {
+	var list []string
+
+	uniq := make(map[string]bool, 8)
+
+	for _, entry := range d.keys {
+		parts := entry.parts
+
+		for i := 0; i < len(parts); i++ {
+			full := strings.Join(parts[:i+1], ".")
+
+			if uniq[full] || (index && !propIndex(parts[i])) || !propMatch(path, parts[:i]) {
+				continue
+			}
+
+			uniq[full] = true
+			list = append(list, parts[i])
+		}
+	}
+
+	return list
+}
+
+// propMatch - соответствие сегментов ключа пути
+func propMatch(path jsonPath, parts []string) bool {
+	if len(path) == 0 {
+		return len(parts) == 0
+	}
+
+	if path[0].kind == kindDeep {
+		for i := range parts {
+			if propMatch(path[1:], parts[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(parts) == 0 {
+		return false
+	}
+
+	switch seg := path[0]; seg.kind {
+	case kindKey:
+		return seg.key == parts[0] && propMatch(path[1:], parts[1:])
+	case kindAll:
+		return propIndex(parts[0]) && propMatch(path[1:], parts[1:])
+	case kindVals:
+		return propMatch(path[1:], parts[1:])
+	}
+
+	return false
+}
+
+func propIndex(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 32)
+	return err == nil
+}
+
+// propLess - порядок ключей по сегментам, числовые сегменты сравниваются как числа
+func propLess(a, b []string) bool {
+	for k := 0; k < len(a) && k < len(b); k++ {
+		if a[k] == b[k] {
+			continue
+		}
+
+		x, ex := strconv.ParseUint(a[k], 10, 64)
+		y, ey := strconv.ParseUint(b[k], 10, 64)
+
+		if ex == nil && ey == nil && x != y {
+			return x < y
+		}
+
+		return a[k] < b[k]
+	}
+
+	return len(a) < len(b)
+}
+
+// propKey - приведение индексов в квадратных скобках к синтаксису JSON-драйвера
+func propKey(name string) string {
+	if strings.IndexByte(name, '[') < 0 {
+		return name
+	}
+
+	return rxPropIndex.ReplaceAllString(name, ".$1")
+}
+
+func (d *propsDriver) parse(src string) error {
+	src = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(src)
+	lines := strings.Split(src, "\n")
+
+	for num := 0; num < len(lines); num++ {
+		line := strings.TrimLeft(lines[num], " \t\f")
+
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		start := num + 1
+
+		// Нечетное количество `\` в конце - продолжение на следующей строке
+		for propContinued(line) && num+1 < len(lines) {
+			num++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[num], " \t\f")
+		}
+
+		if propContinued(line) {
+			line = line[:len(line)-1]
+		}
+
+		key, value := propSplit(line)
+
+		if err := d.store(key, value); err != nil {
+			return ErrPropertiesInvalid.WithReason(err).WithDebug(errx.Debug{"Строка": start})
+		}
+	}
+
+	return nil
+}
+
+func (d *propsDriver) store(key, value string) (err error) {
+	if key, err = propUnescape(key); err != nil {
+		return err
+	}
+
+	if value, err = propUnescape(value); err != nil {
+		return err
+	}
+
+	d.put(propKey(key), value)
+	return nil
+}
+
+func propContinued(line string) bool {
+	var n int
+
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+
+	return n%2 == 1
+}
+
+// propSplit - деление строки на ключ и значение по первому неэкранированному разделителю
+func propSplit(line string) (key, value string) {
+	pos := len(line)
+
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			pos = i
+			break
+		}
+	}
+
+	key, value = line[:pos], strings.TrimLeft(line[pos:], " \t\f")
+
+	// Пробел перед `=` или `:` - тоже часть разделителя
+	if value != "" && (value[0] == '=' || value[0] == ':') {
+		value = strings.TrimLeft(value[1:], " \t\f")
+	}
+
+	return key, value
+}
+
+func propUnescape(s string) (string, error) {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, nil
+	}
+
+	var buf strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			buf.WriteByte(s[i])
+			continue
+		}
+
+		switch i++; s[i] {
+		case 't':
+			buf.WriteByte('\t')
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 'f':
+			buf.WriteByte('\f')
+		case 'u':
+			r, err := propRune(s, i+1)
+
+			if err != nil {
+				return "", err
+			}
+
+			i += 4
+
+			// Символы за пределами BMP записываются суррогатной парой
+			if utf16.IsSurrogate(r) && strings.HasPrefix(s[i+1:], `\u`) {
+				if low, err := propRune(s, i+3); err == nil {
+					if pair := utf16.DecodeRune(r, low); pair != unicode.ReplacementChar {
+						r = pair
+						i += 6
+					}
+				}
+			}
+
+			buf.WriteRune(r)
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+
+	return buf.String(), nil
+}
+
+func propRune(s string, pos int) (rune, error) {
+	if pos+4 > len(s) {
+		return 0, ErrPropertiesInvalid.WithDetail("Неполная последовательность \\u в позиции %d", pos)
+	}
+
+	num, err := strconv.ParseUint(s[pos:pos+4], 16, 16)
+
+	if err != nil {
+		return 0, ErrPropertiesInvalid.WithDetail("Некорректная последовательность \\u%s", s[pos:pos+4])
+	}
+
+	return rune(num), nil
+}
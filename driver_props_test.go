@@ -0,0 +1,90 @@
+package envx_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shestakovda/envx"
+	"github.com/stretchr/testify/assert"
+)
+
+const propsBench = `# Настройки сервиса
+! тоже комментарий
+app.name = Сервис \u0441\u0447\u0435\u0442\u043e\u0432
+app.debug:true
+app.timeout   30s
+app.emoji = \uD83D\uDE00
+app.path = C:\\Program Files\\app
+app.multi = first, \
+            second, \
+            third
+app.tab = a\tb
+key\ with\ spaces = value
+servers[0].host = alpha
+servers[0].port = 8080
+servers[1].host = beta
+servers[10].host = gamma
+tags[0] = red
+tags[1] = green
+db.main.port = 5432
+db.replica.port = 5433
+empty
+`
+
+func TestDriverProperties(t *testing.T) {
+	drv, err := envx.NewDriverProperties([]byte(propsBench))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Сервис счетов", drv.Get("app.name"))
+	assert.Equal(t, "😀", drv.Get("app.emoji"))
+	assert.Equal(t, `C:\Program Files\app`, drv.Get("app.path"))
+	assert.Equal(t, "first, second, third", drv.Get("app.multi"))
+	assert.Equal(t, "a\tb", drv.Get("app.tab"))
+	assert.Equal(t, "value", drv.Get("key with spaces"))
+	assert.Equal(t, "alpha", drv.Get("servers.0.host"))
+	assert.Equal(t, "alpha", drv.Get("servers[0].host"))
+	assert.Equal(t, "", drv.Get("empty"))
+	assert.Equal(t, []string{""}, drv.GetArray("empty"))
+
+	assert.Equal(t, []string{"alpha", "beta", "gamma"}, drv.GetArray("servers.#.host"))
+	assert.Equal(t, []string{"3"}, drv.GetArray("servers.#"))
+	assert.Equal(t, []string{"red", "green"}, drv.GetArray("tags"))
+	assert.Equal(t, []string{"5432", "5433"}, drv.GetArray("db.$.port"))
+	assert.Equal(t, []string{"5432", "5433", "8080"}, drv.GetArray("**.port"))
+	assert.Equal(t, []string{"main", "replica"}, drv.GetArray("db.@"))
+	assert.Nil(t, drv.GetArray("missing"))
+	assert.Nil(t, drv.GetArray("db..port"))
+
+	prv := envx.NewProvider(drv)
+	assert.True(t, prv.Bool("app.debug", false))
+
+	dur, err := prv.Duration("app.timeout", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, dur)
+
+	assert.Equal(t, []string{"db.main.port", "db.replica.port"}, prv.Keys("db."))
+
+	drv.Set("servers[2].host", "delta")
+	assert.Equal(t, []string{"alpha", "beta", "delta", "gamma"}, drv.GetArray("servers.#.host"))
+	drv.Del("servers.2.host")
+	assert.Equal(t, []string{"3"}, drv.GetArray("servers.#"))
+
+	// Get берет первое значение списка, Set добавляет в конец, Del удаляет список целиком
+	assert.Equal(t, "red", drv.Get("tags"))
+	assert.Equal(t, "alpha", drv.Get("servers.#.host"))
+	drv.Set("tags", "blue")
+	assert.Equal(t, []string{"red", "green", "blue"}, drv.GetArray("tags"))
+	assert.Equal(t, "blue", drv.Get("tags[2]"))
+	drv.Set("app.debug", "false")
+	assert.Equal(t, []string{"false"}, drv.GetArray("app.debug"))
+	drv.Del("tags")
+	assert.Nil(t, drv.GetArray("tags"))
+	assert.Nil(t, drv.GetArray("tags.#"))
+
+	_, err = envx.NewDriverProperties([]byte("ok = 1\nbad = \\u12G4"))
+	assert.True(t, errors.Is(err, envx.ErrPropertiesInvalid))
+
+	_, err = envx.NewDriverProperties([]byte(`short = \u12`))
+	assert.True(t, errors.Is(err, envx.ErrPropertiesInvalid))
+}
@@ -0,0 +1,317 @@
+package envx
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shestakovda/errx"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	remoteInterval = time.Minute
+	remoteTagExt   = ".etag"
+)
+
+// RemoteOptions - параметры драйвера удаленной JSON-конфигурации
+type RemoteOptions struct {
+	// URL - адрес JSON-документа
+	URL string
+
+	// Interval - период опроса, если сервер не прислал Cache-Control: max-age
+	Interval time.Duration
+
+	// CacheFile - файл для сохранения последней удачной копии (пусто - не сохранять)
+	CacheFile string
+
+	// Client - HTTP-клиент для запросов (по-умолчанию http.DefaultClient)
+	Client *http.Client
+}
+
+// RemoteStatus - состояние драйвера удаленной JSON-конфигурации
+type RemoteStatus struct {
+	ETag    string
+	Loaded  time.Time
+	Checked time.Time
+	Expires time.Time
+	Stale   bool
+	Cached  bool
+	Err     error
+
+	// CacheErr - ошибка сохранения последней загруженной копии, сами данные при этом обновлены
+	CacheErr error
+}
+
+// NewRemoteDriver - получение аргументов из JSON-документа, периодически загружаемого по HTTP
+func NewRemoteDriver(opt RemoteOptions) (_ RemoteDriver, err error) {
+	if opt.Interval <= 0 {
+		opt.Interval = remoteInterval
+	}
+
+	if opt.Client == nil {
+		opt.Client = http.DefaultClient
+	}
+
+	d := &remoteDriver{
+		opt:  opt,
+		done: make(chan struct{}),
+	}
+
+	if err = d.Refresh(); err != nil {
+		if !d.restore() {
+			return nil, err
+		}
+	}
+
+	go d.poll()
+	return d, nil
+}
+
+type remoteDriver struct {
+	sync.RWMutex
+	opt  RemoteOptions
+	drv  Driver
+	stat RemoteStatus
+	once sync.Once
+	done chan struct{}
+}
+
+func (d *remoteDriver) Get(name string) string        { return d.driver().Get(name) }
+func (d *remoteDriver) Set(name, value string)        { d.driver().Set(name, value) }
+func (d *remoteDriver) Del(name string)               { d.driver().Del(name) }
+func (d *remoteDriver) GetArray(name string) []string { return d.driver().GetArray(name) }
+
+// Документа может не быть до первой удачной загрузки, поэтому драйвер проверяется на каждом вызове
+func (d *remoteDriver) LookupValue(name string) (Value, bool) {
+	if typed := typedDriver(d.driver()); typed != nil {
+		return typed.LookupValue(name)
+	}
+
+	return Value{}, false
+}
+
+func (d *remoteDriver) Keys(prefix string) []string {
+	if enum := enumDriver(d.driver()); enum != nil {
+		return enum.Keys(prefix)
+	}
+
+	return nil
+}
+
+func (d *remoteDriver) Range(fn func(name string, values []string) bool) {
+	if enum := enumDriver(d.driver()); enum != nil {
+		enum.Range(fn)
+	}
+}
+
+func (d *remoteDriver) Status() RemoteStatus {
+	d.RLock()
+	defer d.RUnlock()
+
+	// Даем запас в один интервал опроса, чтобы не считать устаревшими данные, которые как раз обновляются
+	stat := d.stat
+	stat.Stale = stat.Err != nil || time.Now().After(stat.Expires.Add(d.opt.Interval))
+	return stat
+}
+
+func (d *remoteDriver) Close() {
+	d.once.Do(func() { close(d.done) })
+}
+
+func (d *remoteDriver) Refresh() (err error) {
+	var req *http.Request
+	var resp *http.Response
+
+	d.RLock()
+	etag := d.stat.ETag
+	d.RUnlock()
+
+	if req, err = http.NewRequest(http.MethodGet, d.opt.URL, nil); err != nil {
+		return d.fail(ErrRemoteUnavailable.WithReason(err).WithDebug(errx.Debug{"Адрес": d.opt.URL}))
+	}
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	if resp, err = d.opt.Client.Do(req); err != nil {
+		return d.fail(ErrRemoteUnavailable.WithReason(err).WithDebug(errx.Debug{"Адрес": d.opt.URL}))
+	}
+	defer resp.Body.Close()
+
+	now := time.Now()
+	age, store := cacheControl(resp.Header.Get("Cache-Control"), d.opt.Interval)
+
+	if resp.StatusCode == http.StatusNotModified {
+		d.Lock()
+		defer d.Unlock()
+
+		d.stat.Checked = now
+		d.stat.Expires = now.Add(age)
+		d.stat.Err = nil
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return d.fail(ErrRemoteUnavailable.WithDetail("Сервер вернул статус %d", resp.StatusCode).WithDebug(errx.Debug{
+			"Адрес": d.opt.URL,
+		}))
+	}
+
+	var body []byte
+
+	if body, err = ioutil.ReadAll(resp.Body); err != nil {
+		return d.fail(ErrRemoteUnavailable.WithReason(err).WithDebug(errx.Debug{"Адрес": d.opt.URL}))
+	}
+
+	if !gjson.ValidBytes(body) {
+		return d.fail(ErrRemoteInvalid.WithDetail("Тело ответа не является корректным JSON").WithDebug(errx.Debug{
+			"Адрес": d.opt.URL,
+		}))
+	}
+
+	etag = resp.Header.Get("ETag")
+
+	d.Lock()
+	d.drv = NewDriverJSONReadOnly(body)
+	d.stat = RemoteStatus{
+		ETag:    etag,
+		Loaded:  now,
+		Checked: now,
+		Expires: now.Add(age),
+	}
+	d.Unlock()
+
+	// Свежие данные уже используются, ошибка сохранения копии видна только в Status
+	if store && d.opt.CacheFile != "" {
+		if exp := d.persist(body, etag); exp != nil {
+			d.Lock()
+			d.stat.CacheErr = exp
+			d.Unlock()
+		}
+	}
+
+	return nil
+}
+
+func (d *remoteDriver) driver() Driver {
+	d.RLock()
+	defer d.RUnlock()
+	return d.drv
+}
+
+func (d *remoteDriver) fail(err errx.Error) error {
+	d.Lock()
+	defer d.Unlock()
+
+	d.stat.Checked = time.Now()
+	d.stat.Err = err
+	return err
+}
+
+func (d *remoteDriver) poll() {
+	for {
+		d.RLock()
+		wait := time.Until(d.stat.Expires)
+		d.RUnlock()
+
+		// Если сервер недоступен, не стоит долбить его чаще обычного интервала
+		if wait <= 0 {
+			wait = d.opt.Interval
+		}
+
+		select {
+		case <-d.done:
+			return
+		case <-time.After(wait):
+			_ = d.Refresh()
+		}
+	}
+}
+
+// restore - загрузка последней удачной копии с диска, если сервер недоступен при старте
+func (d *remoteDriver) restore() bool {
+	if d.opt.CacheFile == "" {
+		return false
+	}
+
+	body, err := ioutil.ReadFile(d.opt.CacheFile)
+
+	if err != nil || !gjson.ValidBytes(body) {
+		return false
+	}
+
+	etag, _ := ioutil.ReadFile(d.opt.CacheFile + remoteTagExt)
+
+	d.Lock()
+	defer d.Unlock()
+
+	d.drv = NewDriverJSONReadOnly(body)
+	d.stat.ETag = strings.TrimSpace(string(etag))
+	d.stat.Cached = true
+
+	if info, err := os.Stat(d.opt.CacheFile); err == nil {
+		d.stat.Loaded = info.ModTime()
+	}
+
+	return true
+}
+
+func (d *remoteDriver) persist(body []byte, etag string) errx.Error {
+	if err := writeFileAtomic(d.opt.CacheFile, body); err != nil {
+		return ErrRemoteCache.WithReason(err).WithDebug(errx.Debug{"Файл": d.opt.CacheFile})
+	}
+
+	if err := writeFileAtomic(d.opt.CacheFile+remoteTagExt, []byte(etag)); err != nil {
+		return ErrRemoteCache.WithReason(err).WithDebug(errx.Debug{"Файл": d.opt.CacheFile + remoteTagExt})
+	}
+
+	return nil
+}
+
+func writeFileAtomic(name string, data []byte) (err error) {
+	var tmp *os.File
+
+	if tmp, err = ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".*"); err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+// cacheControl - время жизни ответа и разрешение на сохранение по заголовку Cache-Control
+func cacheControl(header string, def time.Duration) (age time.Duration, store bool) {
+	age, store = def, true
+
+	for _, item := range strings.Split(header, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+
+		switch {
+		case item == "no-store":
+			store = false
+		case strings.HasPrefix(item, "max-age="):
+			if sec, err := strconv.ParseUint(strings.TrimPrefix(item, "max-age="), 10, 32); err == nil && sec > 0 {
+				age = time.Duration(sec) * time.Second
+			}
+		}
+	}
+
+	return age, store
+}
@@ -0,0 +1,103 @@
+package envx_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shestakovda/envx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteDriver(t *testing.T) {
+	const etag = `"v1"`
+
+	var hits, fresh int32
+	var down atomic.Value
+
+	down.Store(false)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+
+		if down.Load().(bool) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		atomic.AddInt32(&fresh, 1)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "max-age=3600")
+		_, _ = w.Write([]byte(`{"db": {"host": "localhost", "ports": [5432, 5433]}}`))
+	}))
+	defer srv.Close()
+
+	cache := filepath.Join(t.TempDir(), "config.json")
+
+	drv, err := envx.NewRemoteDriver(envx.RemoteOptions{URL: srv.URL, CacheFile: cache})
+	assert.NoError(t, err)
+	defer drv.Close()
+
+	assert.Equal(t, "localhost", drv.Get("db.host"))
+	assert.Equal(t, []string{"5432", "5433"}, drv.GetArray("db.ports"))
+
+	stat := drv.Status()
+	assert.Equal(t, etag, stat.ETag)
+	assert.False(t, stat.Stale)
+	assert.False(t, stat.Cached)
+	assert.NoError(t, stat.Err)
+
+	// Повторный запрос с ETag не должен перекачивать документ
+	assert.NoError(t, drv.Refresh())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fresh))
+	assert.Equal(t, "localhost", drv.Get("db.host"))
+
+	// Ошибки сервера не портят последнюю удачную копию
+	down.Store(true)
+
+	if err = drv.Refresh(); assert.Error(t, err) {
+		assert.True(t, errors.Is(err, envx.ErrRemoteUnavailable))
+	}
+
+	stat = drv.Status()
+	assert.True(t, stat.Stale)
+	assert.True(t, errors.Is(stat.Err, envx.ErrRemoteUnavailable))
+	assert.Equal(t, "localhost", drv.Get("db.host"))
+
+	// Холодный старт с диска при недоступном сервере
+	cold, err := envx.NewRemoteDriver(envx.RemoteOptions{URL: srv.URL, CacheFile: cache})
+	assert.NoError(t, err)
+	defer cold.Close()
+
+	assert.Equal(t, "localhost", cold.Get("db.host"))
+	assert.True(t, cold.Status().Cached)
+	assert.Equal(t, etag, cold.Status().ETag)
+
+	// Без копии на диске старт невозможен
+	none, err := envx.NewRemoteDriver(envx.RemoteOptions{URL: srv.URL})
+	assert.True(t, errors.Is(err, envx.ErrRemoteUnavailable))
+	assert.Nil(t, none)
+
+	// Ошибка сохранения копии не отменяет загруженные данные
+	down.Store(false)
+
+	broken, err := envx.NewRemoteDriver(envx.RemoteOptions{URL: srv.URL, CacheFile: filepath.Join(cache, "missing", "config.json")})
+	assert.NoError(t, err)
+	defer broken.Close()
+
+	assert.Equal(t, "localhost", broken.Get("db.host"))
+
+	stat = broken.Status()
+	assert.NoError(t, stat.Err)
+	assert.False(t, stat.Stale)
+	assert.True(t, errors.Is(stat.CacheErr, envx.ErrRemoteCache))
+}
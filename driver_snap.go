@@ -0,0 +1,130 @@
+package envx
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// NewEnvSnapshotDriver - работа с копией окружения вместо окружения процесса
+// Если env равен nil, копируется os.Environ(), правила префикса и регистра те же, что у NewEnvDriver
+func NewEnvSnapshotDriver(pfx string, env []string) EnvSnapshot {
+	if env == nil {
+		env = os.Environ()
+	}
+
+	d := &snapDriver{
+		pfx:  strings.ToUpper(pfx) + "_",
+		data: make(map[string]string, len(env)),
+	}
+
+	for i := range env {
+		if pair := strings.SplitN(env[i], "=", 2); len(pair) == 2 && pair[0] != "" {
+			d.data[pair[0]] = pair[1]
+		}
+	}
+
+	return d
+}
+
+type snapDriver struct {
+	sync.RWMutex
+	pfx  string
+	data map[string]string
+}
+
+func (d *snapDriver) Set(name, value string) {
+	d.Lock()
+	defer d.Unlock()
+	d.data[d.pfx+strings.ToUpper(name)] = value
+}
+
+func (d *snapDriver) Get(name string) string {
+	d.RLock()
+	defer d.RUnlock()
+
+	return strings.TrimSpace(d.data[d.pfx+strings.ToUpper(name)])
+}
+
+func (d *snapDriver) Del(name string) {
+	d.Lock()
+	defer d.Unlock()
+	delete(d.data, d.pfx+strings.ToUpper(name))
+}
+
+func (d *snapDriver) GetArray(name string) []string {
+	d.RLock()
+	defer d.RUnlock()
+
+	if val, ok := d.data[d.pfx+strings.ToUpper(name)]; ok {
+		return []string{strings.TrimSpace(val)}
+	}
+
+	return nil
+}
+
+func (d *snapDriver) Lookup(name string) (string, bool) {
+	d.RLock()
+	defer d.RUnlock()
+
+	val, ok := d.data[d.pfx+strings.ToUpper(name)]
+	return strings.TrimSpace(val), ok
+}
+
+func (d *snapDriver) Environ() []string {
+	d.RLock()
+	defer d.RUnlock()
+
+	env := make([]string, 0, len(d.data))
+
+	for key := range d.data {
+		env = append(env, key+"="+d.data[key])
+	}
+
+	sort.Strings(env)
+	return env
+}
+
+func (d *snapDriver) Snapshot(names ...string) map[string][]string {
+	d.RLock()
+	defer d.RUnlock()
+
+	snap := make(map[string][]string, len(names))
+
+	for _, name := range names {
+		if val, ok := d.data[d.pfx+strings.ToUpper(name)]; ok {
+			snap[name] = []string{val}
+		} else {
+			snap[name] = nil
+		}
+	}
+
+	return snap
+}
+
+// Restore - в окружении по ключу одно значение, поэтому возвращается первое из сохраненных
+func (d *snapDriver) Restore(snap map[string][]string) {
+	d.Lock()
+	defer d.Unlock()
+
+	for name, values := range snap {
+		if len(values) == 0 {
+			delete(d.data, d.pfx+strings.ToUpper(name))
+		} else {
+			d.data[d.pfx+strings.ToUpper(name)] = values[0]
+		}
+	}
+}
+
+func (d *snapDriver) Keys(prefix string) []string {
+	return enumKeys(envItems(d.pfx, d.Environ()), strings.ToUpper(prefix))
+}
+
+func (d *snapDriver) Range(fn func(name string, values []string) bool) {
+	enumRange(envItems(d.pfx, d.Environ()), fn)
+}
+
+func (d *snapDriver) normalize(name string) string {
+	return strings.ToUpper(name)
+}
@@ -0,0 +1,430 @@
+package envx
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/shestakovda/errx"
+	"github.com/tidwall/gjson"
+)
+
+// NewDriverJSONStream - получение аргументов из большого JSON-документа, читаемого потоком
+//
+// * Документ читается один раз и сохраняется без пробелов во временный файл, в памяти он не хранится
+// * Каждый запрос заново читает временный файл потоком, в памяти оказываются только найденные поддеревья
+// * Возвращает ErrJSONInvalid, если документ поврежден, и ErrStreamSpool, если не удалось записать копию
+func NewDriverJSONStream(src io.Reader) (_ StreamDriver, err error) {
+	var file *os.File
+
+	if file, err = os.CreateTemp("", "envx-*.json"); err != nil {
+		return nil, ErrStreamSpool.WithReason(err)
+	}
+
+	drv := &streamDriver{file: file}
+
+	// Там, где это возможно, файл удаляется сразу и исчезнет вместе с дескриптором
+	if os.Remove(file.Name()) != nil {
+		drv.name = file.Name()
+	}
+
+	defer func() {
+		if err != nil {
+			_ = drv.Close()
+		}
+	}()
+
+	spool := &streamSpool{
+		dec: json.NewDecoder(bufio.NewReader(src)),
+		buf: bufio.NewWriter(file),
+	}
+
+	spool.dec.UseNumber()
+
+	if err = spool.node(); err == nil {
+		// После корневого значения в документе ничего быть не должно
+		if _, err = spool.dec.Token(); err == io.EOF {
+			err = nil
+		} else if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+	}
+
+	if err != nil {
+		return nil, ErrJSONInvalid.WithReason(err).WithDebug(errx.Debug{"Смещение": spool.dec.InputOffset()})
+	}
+
+	if err = spool.buf.Flush(); err != nil {
+		return nil, ErrStreamSpool.WithReason(err).WithDebug(errx.Debug{"Файл": file.Name()})
+	}
+
+	drv.size = int64(spool.size)
+	return drv, nil
+}
+
+// streamDriver - копия документа не меняется после создания, а ReadAt безопасен
+// для одновременных вызовов, поэтому блокировки не нужны
+type streamDriver struct {
+	file *os.File
+	name string
+	size int64
+}
+
+func (d *streamDriver) Set(name, value string) { _ = d.Update(name, value) }
+func (d *streamDriver) Del(name string)        { _ = d.Remove(name) }
+
+func (d *streamDriver) Update(name, value string) error {
+	return ErrReadOnly.WithDebug(errx.Debug{argName: name})
+}
+
+func (d *streamDriver) Remove(name string) error {
+	return ErrReadOnly.WithDebug(errx.Debug{argName: name})
+}
+
+func (d *streamDriver) Close() (err error) {
+	err = d.file.Close()
+
+	if d.name != "" {
+		if rerr := os.Remove(d.name); err == nil {
+			err = rerr
+		}
+	}
+
+	return err
+}
+
+func (d *streamDriver) Get(name string) (val string) {
+	path, err := parsePath(name)
+
+	if err != nil {
+		return ""
+	}
+
+	_, exact := path.exact()
+
+	_ = d.scan(path, func(raw []byte, states []int) bool {
+		res := gjson.ParseBytes(raw)
+
+		// Точный путь - как в gjson, иначе первое из найденного внутри
+		if exact {
+			val = res.String()
+			return false
+		}
+
+		if list := evalStates(path, states, res); len(list) > 0 {
+			val = list[0]
+			return false
+		}
+
+		return true
+	})
+
+	return val
+}
+
+func (d *streamDriver) GetArray(name string) []string {
+	list, _ := d.Select(name)
+	return list
+}
+
+func (d *streamDriver) Select(name string) (_ []string, err error) {
+	var path jsonPath
+	var list []string
+
+	if path, err = parsePath(name); err != nil {
+		return nil, err
+	}
+
+	err = d.scan(path, func(raw []byte, states []int) bool {
+		if found := evalStates(path, states, gjson.ParseBytes(raw)); found != nil {
+			list = append(nonNil(list), found...)
+		}
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// scan - потоковый обход копии документа с вызовом fn для каждого поддерева, требующего разбора
+func (d *streamDriver) scan(path jsonPath, fn func(raw []byte, states []int) bool) (err error) {
+	dec := json.NewDecoder(bufio.NewReader(io.NewSectionReader(d.file, 0, d.size)))
+	dec.UseNumber()
+
+	if _, err = streamNode(dec, path, closeStates(path, []int{0}), fn); err != nil {
+		return ErrStreamSpool.WithReason(err).WithDebug(errx.Debug{"Смещение": dec.InputOffset()})
+	}
+
+	return nil
+}
+
+// streamNode - обработка очередного значения, states - позиции пути, которые могут на нем совпасть
+func streamNode(dec *json.Decoder, path jsonPath, states []int, fn func([]byte, []int) bool) (stop bool, err error) {
+	var tok json.Token
+
+	if needRaw(path, states) {
+		var raw json.RawMessage
+
+		if err = dec.Decode(&raw); err != nil {
+			return true, err
+		}
+
+		return !fn(raw, states), nil
+	}
+
+	if tok, err = dec.Token(); err != nil {
+		return true, err
+	}
+
+	delim, ok := tok.(json.Delim)
+
+	if !ok {
+		return false, nil
+	}
+
+	// Ключи объекта, уже совпавшие с сегментами пути
+	var seen map[string]bool
+
+	for i := 0; dec.More(); i++ {
+		var next []int
+		var hit bool
+
+		if delim == '{' {
+			if tok, err = dec.Token(); err != nil {
+				return true, err
+			}
+
+			key := tok.(string)
+
+			if next, hit = stepStates(path, states, key, false, seen[key]); hit {
+				if seen == nil {
+					seen = make(map[string]bool, 1)
+				}
+
+				seen[key] = true
+			}
+		} else {
+			next, _ = stepStates(path, states, strconv.Itoa(i), true, false)
+		}
+
+		if len(next) == 0 {
+			err = skipValue(dec)
+		} else {
+			stop, err = streamNode(dec, path, next, fn)
+		}
+
+		if err != nil || stop {
+			return true, err
+		}
+	}
+
+	// Закрывающая скобка
+	_, err = dec.Token()
+	return false, err
+}
+
+// needRaw - узел нужно разобрать целиком: путь совпал или дальше нужен весь объект/массив
+func needRaw(path jsonPath, states []int) bool {
+	for _, s := range states {
+		if s == len(path) || path[s].kind == kindKeys || (path[s].kind == kindAll && s == len(path)-1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stepStates - позиции пути, которые могут совпасть на дочернем узле, hit - ключ совпал с сегментом пути
+// При повторе ключа (dup), как и в gjson, с сегментом пути совпадает только первое значение
+func stepStates(path jsonPath, states []int, key string, arr, dup bool) (next []int, hit bool) {
+
+	add := func(s int) {
+		if !hasState(next, s) {
+			next = append(next, s)
+		}
+	}
+
+	for _, s := range states {
+		if s == len(path) {
+			continue
+		}
+
+		switch seg := path[s]; seg.kind {
+		case kindKey:
+			if seg.key == key && !dup {
+				hit = true
+				add(s + 1)
+			}
+		case kindAll:
+			if arr {
+				add(s + 1)
+			}
+		case kindVals:
+			add(s + 1)
+		case kindDeep:
+			add(s)
+		}
+	}
+
+	return closeStates(path, next), hit
+}
+
+// closeStates - `**` может не совпасть ни с одним уровнем, поэтому сразу допустима и следующая позиция
+func closeStates(path jsonPath, states []int) []int {
+	for i := range states {
+		if s := states[i]; s < len(path) && path[s].kind == kindDeep && !hasState(states, s+1) {
+			states = append(states, s+1)
+		}
+	}
+
+	return states
+}
+
+// evalStates - сбор значений поддерева по всем позициям пути без повторов
+func evalStates(path jsonPath, states []int, res gjson.Result) []string {
+	var list []string
+	var found bool
+
+	for _, s := range states {
+		// Позицию после `**` уже покрывает сама `**`
+		if s > 0 && path[s-1].kind == kindDeep && hasState(states, s-1) {
+			continue
+		}
+
+		list = path[s:].walk(res, list, &found)
+	}
+
+	if !found {
+		return nil
+	}
+
+	return nonNil(list)
+}
+
+func hasState(states []int, s int) bool {
+	for i := range states {
+		if states[i] == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return make([]string, 0, 8)
+	}
+
+	return list
+}
+
+// skipValue - пропуск значения без сохранения в памяти
+func skipValue(dec *json.Decoder) error {
+	var depth int
+
+	for {
+		tok, err := dec.Token()
+
+		if err != nil {
+			return err
+		}
+
+		if delim, ok := tok.(json.Delim); ok {
+			if delim == '{' || delim == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// streamSpool - запись копии документа без пробелов с проверкой его корректности
+type streamSpool struct {
+	dec  *json.Decoder
+	buf  *bufio.Writer
+	tmp  []byte
+	size int
+}
+
+// node - чтение и запись очередного значения
+func (x *streamSpool) node() error {
+	tok, err := x.dec.Token()
+
+	if err != nil {
+		return err
+	}
+
+	switch val := tok.(type) {
+	case json.Delim:
+		return x.nested(val)
+	case string:
+		x.write(appendString(x.tmp[:0], val))
+	case json.Number:
+		x.write(append(x.tmp[:0], val...))
+	case bool:
+		x.write(strconv.AppendBool(x.tmp[:0], val))
+	default:
+		x.write(append(x.tmp[:0], "null"...))
+	}
+
+	return nil
+}
+
+// nested - чтение объекта или массива до закрывающей скобки
+func (x *streamSpool) nested(open json.Delim) error {
+	x.write(append(x.tmp[:0], byte(open)))
+
+	for i := 0; x.dec.More(); i++ {
+		if i > 0 {
+			x.write(append(x.tmp[:0], ','))
+		}
+
+		if open == '{' {
+			tok, err := x.dec.Token()
+
+			if err != nil {
+				return err
+			}
+
+			x.write(append(appendString(x.tmp[:0], tok.(string)), ':'))
+		}
+
+		if err := x.node(); err != nil {
+			return err
+		}
+	}
+
+	tok, err := x.dec.Token()
+
+	if err != nil {
+		return err
+	}
+
+	x.write(append(x.tmp[:0], byte(tok.(json.Delim))))
+	return nil
+}
+
+// write - запись фрагмента, буфер фрагмента переиспользуется
+// Ошибка записи запоминается в bufio.Writer и проверяется при Flush
+func (x *streamSpool) write(p []byte) {
+	n, _ := x.buf.Write(p)
+	x.size += n
+	x.tmp = p[:0]
+}
+
+// appendString - строка в виде JSON
+func appendString(buf []byte, s string) []byte {
+	raw, _ := json.Marshal(s)
+	return append(buf, raw...)
+}
@@ -0,0 +1,170 @@
+package envx_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/shestakovda/envx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDriverJSONStream(t *testing.T) {
+	var compact bytes.Buffer
+
+	// Объекты и массивы драйвер отдает без пробелов
+	js := []byte(jsBenchEvent)
+	assert.NoError(t, json.Compact(&compact, js))
+	mem := envx.NewDriverJSON(compact.Bytes())
+	drv, err := envx.NewDriverJSONStream(bytes.NewReader(js))
+	assert.NoError(t, err)
+	defer drv.Close()
+
+	paths := []string{
+		"Направление",
+		"Документы",
+		"Документы.1.Наименование",
+		"Документы.#.КодДокумента",
+		"Документы.#.Подписи.#.ИдФайла",
+		"Документы.#",
+		"Документы.0.@",
+		"Документы.$.Зашифрован",
+		"**.ИдФайла",
+		"**.Подписи.#.Присоединенная",
+		"missing",
+		"Документы.5.ИдФайла",
+	}
+
+	for _, path := range paths {
+		assert.Equal(t, mem.GetArray(path), drv.GetArray(path), path)
+	}
+
+	// Get у JSON-драйвера и потокового совпадает и для путей с шаблонами
+	for _, path := range paths {
+		assert.Equal(t, mem.Get(path), drv.Get(path), path)
+	}
+
+	nested := `{"a": {"x": {"c": 1}, "y": {"c": [2, 3]}}, "b": [{"c": 4}]}`
+	jsd := envx.NewDriverJSON([]byte(nested))
+	std, err := envx.NewDriverJSONStream(strings.NewReader(nested))
+	assert.NoError(t, err)
+	defer std.Close()
+
+	for _, path := range []string{"**.c", "a.$.c", "b.#.c", "a.@", "a.$.d"} {
+		assert.Equal(t, jsd.Get(path), std.Get(path), path)
+	}
+
+	assert.Equal(t, "1", jsd.Get("**.c"))
+	assert.Equal(t, "1", jsd.Get("a.$.c"))
+	assert.Equal(t, "4", jsd.Get("b.#.c"))
+
+	val, ok := jsd.(envx.Typed).LookupValue("a.y.$")
+	assert.True(t, ok)
+	assert.Equal(t, "2", val.String())
+	_, ok = jsd.(envx.Typed).LookupValue("a.$.d")
+	assert.False(t, ok)
+
+	// Для путей с шаблонами Get возвращает первое найденное значение
+	assert.Equal(t, "01", drv.Get("Документы.#.КодДокумента"))
+	assert.Equal(t, "3", drv.Get("Документы.#"))
+	assert.Equal(t, "ТипДокумента", drv.Get("Документы.0.@"))
+	assert.Equal(t, "", drv.Get("missing"))
+
+	// Запись не поддерживается: Set и Del ничего не делают, Update и Remove возвращают ошибку
+	drv.Set("Направление", "ololo")
+	drv.Del("Направление")
+	assert.Equal(t, "ФНС", drv.Get("Направление"))
+	assert.True(t, errors.Is(drv.Update("Направление", "ololo"), envx.ErrReadOnly))
+	assert.True(t, errors.Is(drv.Remove("Направление"), envx.ErrReadOnly))
+
+	_, err = drv.Select("a..b")
+	assert.True(t, errors.Is(err, envx.ErrPathInvalid))
+
+	for _, src := range []string{`{"a": [1, 2}`, `{"a": 1} {}`, ``} {
+		bad, err := envx.NewDriverJSONStream(strings.NewReader(src))
+		assert.True(t, errors.Is(err, envx.ErrJSONInvalid), src)
+		assert.Nil(t, bad)
+	}
+
+	// При повторе ключа действует первое значение, как и в gjson
+	dup, err := envx.NewDriverJSONStream(strings.NewReader(`{"a": 1, "a": 2, "b": {"<c>": "&"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "1", dup.Get("a"))
+	assert.Equal(t, []string{"1"}, dup.GetArray("a"))
+	assert.Equal(t, "&", dup.Get("b.<c>"))
+	assert.Equal(t, []string{"&"}, dup.GetArray("b.$"))
+
+	// После закрытия копии документа значений нет
+	assert.NoError(t, dup.Close())
+	assert.Equal(t, "", dup.Get("a"))
+	_, err = dup.Select("a")
+	assert.True(t, errors.Is(err, envx.ErrStreamSpool))
+}
+
+func TestDriverJSONStreamLarge(t *testing.T) {
+	const size = 20000
+
+	// Документ читается один раз, поэтому подходит и источник без перемотки
+	drv, err := envx.NewDriverJSONStream(&genReader{size: size})
+	assert.NoError(t, err)
+	defer drv.Close()
+
+	assert.Equal(t, "end", drv.Get("tail"))
+	assert.Equal(t, fmt.Sprint(size-1), drv.Get(fmt.Sprintf("items.%d.id", size-1)))
+	assert.Equal(t, []string{"7"}, drv.GetArray("items.7.id"))
+	assert.Len(t, drv.GetArray("items.#.id"), size)
+}
+
+func TestDriverJSONStreamMemory(t *testing.T) {
+	var before, after runtime.MemStats
+
+	// Около 3 МБ документа не должны оставаться в памяти ни копией, ни индексом
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	drv, err := envx.NewDriverJSONStream(&genReader{size: 100000})
+	assert.NoError(t, err)
+	defer drv.Close()
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	assert.Less(t, int64(after.HeapAlloc)-int64(before.HeapAlloc), int64(1<<20))
+	assert.Equal(t, "99999", drv.Get("items.99999.id"))
+	runtime.KeepAlive(drv)
+}
+
+// genReader - документ, который генерируется на лету и никогда не хранится целиком
+type genReader struct {
+	size int
+	pos  int
+	buf  bytes.Buffer
+}
+
+func (r *genReader) Read(p []byte) (int, error) {
+	for r.buf.Len() < len(p) && r.pos <= r.size+1 {
+		switch {
+		case r.pos == 0:
+			r.buf.WriteString(`{"items": [`)
+		case r.pos <= r.size:
+			if r.pos > 1 {
+				r.buf.WriteByte(',')
+			}
+			fmt.Fprintf(&r.buf, `{"id": %d, "name": "item"}`, r.pos-1)
+		default:
+			r.buf.WriteString(`], "tail": "end"}`)
+		}
+		r.pos++
+	}
+
+	if r.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+
+	return r.buf.Read(p)
+}
@@ -3,9 +3,13 @@ package envx_test
 import (
 	"errors"
 	"net/http"
+	"os"
+	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/shestakovda/envx"
+	"github.com/shestakovda/envx/envxtest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,13 +29,73 @@ func testDriver(t *testing.T, d envx.Driver) {
 }
 
 func TestMemDriver(t *testing.T) {
-	testDriver(t, envx.NewMemDriver(16))
+	envxtest.DriverConformance(t, func(t *testing.T) envx.Driver {
+		return envx.NewMemDriver(16)
+	})
+
+	drv := envx.NewMemDriver(16)
+	testDriver(t, drv)
+
+	drv.Set(k, v)
+	drv.Set(k, wtf)
+	assert.Equal(t, tv, drv.Get(k))
+	assert.Equal(t, []string{tv, wtf}, drv.GetArray(k))
+
+	// Наружу отдаются копии, внутреннее состояние не меняется
+	arr := drv.GetArray(k)
+	arr[0] = e
+	assert.Equal(t, []string{tv, wtf}, drv.GetArray(k))
+
+	drv.Replace(k, wtf)
+	assert.Equal(t, []string{wtf}, drv.GetArray(k))
+
+	src := []string{" a ", "b"}
+	drv.SetArray(k, src)
+	src[1] = "c"
+	assert.Equal(t, "a", drv.Get(k))
+	assert.Equal(t, []string{"a", "b"}, drv.GetArray(k))
+
+	drv.SetArray(k, []string{})
+	assert.Equal(t, e, drv.Get(k))
+	assert.Equal(t, []string{}, drv.GetArray(k))
+
+	drv.SetArray(k, nil)
+	assert.Nil(t, drv.GetArray(k))
 }
 
 func TestEnvDriver(t *testing.T) {
+	envxtest.DriverConformance(t, func(t *testing.T) envx.Driver {
+		return envx.NewEnvDriver("test")
+	}, envxtest.WithoutMultiValue())
+
 	testDriver(t, envx.NewEnvDriver("test"))
 }
 
+func TestEnvSnapshotDriver(t *testing.T) {
+	envxtest.DriverConformance(t, func(t *testing.T) envx.Driver {
+		return envx.NewEnvSnapshotDriver("test", []string{})
+	}, envxtest.WithoutMultiValue())
+
+	t.Setenv("TEST_KEY", "process")
+
+	drv := envx.NewEnvSnapshotDriver("test", nil)
+	assert.Equal(t, "process", drv.Get(k))
+
+	// Изменения не выходят за пределы копии
+	drv.Del(k)
+	testDriver(t, drv)
+	drv.Set(k, v)
+	assert.Equal(t, "process", os.Getenv("TEST_KEY"))
+
+	drv = envx.NewEnvSnapshotDriver("app", []string{"PATH=/bin", "APP_DEBUG= yes ", "APP_PORT=8080", "broken"})
+	drv.Set("name", "envx")
+
+	assert.Equal(t, "yes", drv.Get("debug"))
+	assert.Equal(t, []string{"DEBUG", "NAME", "PORT"}, drv.Keys(""))
+	assert.Equal(t, []string{"APP_DEBUG= yes ", "APP_NAME=envx", "APP_PORT=8080", "PATH=/bin"}, drv.Environ())
+	assert.True(t, envx.NewProvider(drv).Bool("debug", false))
+}
+
 func TestHTTPDriver(t *testing.T) {
 	req, err := http.NewRequest("POST", "", nil)
 	assert.NoError(t, err)
@@ -48,6 +112,163 @@ func TestHTTPDriver(t *testing.T) {
 	assert.NotNil(t, drv)
 
 	testDriver(t, drv)
+
+	envxtest.DriverConformance(t, func(t *testing.T) envx.Driver {
+		req, err := http.NewRequest("GET", "", nil)
+		assert.NoError(t, err)
+
+		drv, err := envx.NewHTTPDriver(req)
+		assert.NoError(t, err)
+		return drv
+	}, envxtest.WithoutMultiValue())
+
+	// Заголовки, cookie и параметры пути
+	req, err = http.NewRequest("GET", "/orders/42?id=form", nil)
+	assert.NoError(t, err)
+	req.Header.Add("X-Request-ID", " req-1 ")
+	req.Header.Add("X-Request-ID", "req-2")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+	req.SetPathValue("id", "42")
+
+	drv, err = envx.NewHTTPDriver(req)
+	assert.NoError(t, err)
+
+	// Параметры пути копируются при создании драйвера
+	req.SetPathValue("id", "41")
+	assert.Equal(t, "42", drv.Get("path:id"))
+	req.SetPathValue("id", "42")
+
+	assert.Equal(t, "req-1", drv.Get("header:x-request-id"))
+	assert.Equal(t, []string{"req-1", "req-2"}, drv.GetArray("header:X-Request-Id"))
+	assert.Equal(t, "s1", drv.Get("cookie:session"))
+	assert.Equal(t, "42", drv.Get("path:id"))
+	assert.Equal(t, "form", drv.Get("id"))
+	assert.Nil(t, drv.GetArray("header:missing"))
+	assert.Nil(t, drv.GetArray("cookie:missing"))
+	assert.Nil(t, drv.GetArray("path:missing"))
+
+	prv := envx.NewProvider(drv)
+	num, err := prv.Uint64("path:id", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), num)
+
+	// Изменения не затрагивают сам запрос
+	drv.Set("header:x-trace", "t1")
+	drv.Set("path:id", "43")
+	drv.Set("id", "changed")
+	assert.Equal(t, "t1", drv.Get("header:X-Trace"))
+	assert.Equal(t, "43", drv.Get("path:id"))
+	assert.Equal(t, "", req.Header.Get("X-Trace"))
+	assert.Equal(t, "42", req.PathValue("id"))
+	assert.Equal(t, "form", req.Form.Get("id"))
+	drv.Del("header:x-trace")
+	drv.Del("path:id")
+	assert.Nil(t, drv.GetArray("header:x-trace"))
+	assert.Nil(t, drv.GetArray("path:id"))
+	drv.Set("cookie:session", "s2")
+	drv.Del("cookie:session")
+	assert.Equal(t, "s1", drv.Get("cookie:session"))
+	assert.Equal(t, []string{"id"}, prv.Keys(""))
+
+	// Наружу отдаются копии без пробелов, исходные значения не меняются
+	req, err = http.NewRequest("GET", "/?list=+a+&list=b", nil)
+	assert.NoError(t, err)
+
+	drv, err = envx.NewHTTPDriver(req)
+	assert.NoError(t, err)
+
+	arr := drv.GetArray("list")
+	assert.Equal(t, []string{"a", "b"}, arr)
+	arr[0] = wtf
+	assert.Equal(t, []string{"a", "b"}, drv.GetArray("list"))
+	assert.Equal(t, []string{" a ", "b"}, req.Form["list"])
+
+	// Вложенные ключи формы
+	req, err = http.NewRequest("GET", "/?user[name]=+x+&user[role][]=admin&user[role][]=dev"+
+		"&tags[]=a&tags[]=b&items[0][id]=7&items[1][id]=8&items[1][name]=pen&multi[k]=1&multi[k]=2&bad[x=1"+
+		"&huge[3000000][id]=1&items[99][id]=9", nil)
+	assert.NoError(t, err)
+
+	drv, err = envx.NewHTTPDriver(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "x", drv.Get("user.name"))
+	assert.Equal(t, "x", drv.Get("user[name]"))
+	assert.Equal(t, []string{"admin", "dev"}, drv.GetArray("user.role"))
+	assert.Equal(t, []string{"a", "b"}, drv.GetArray("tags"))
+	assert.Equal(t, []string{"7", "8"}, drv.GetArray("items.#.id"))
+	assert.Equal(t, "2", drv.Get("items.#"))
+	assert.Equal(t, []string{"1", "2"}, drv.GetArray("multi.k"))
+	assert.Equal(t, "1", drv.Get("bad[x"))
+	assert.Nil(t, drv.GetArray("bad"))
+
+	// Индексы за пределами числа значений формы не раздувают массивы
+	assert.Nil(t, drv.GetArray("huge"))
+	assert.Equal(t, "1", drv.Get("huge[3000000][id]"))
+	assert.Equal(t, "9", drv.Get("items[99][id]"))
+
+	var items []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	prv = envx.NewProvider(drv)
+	assert.NoError(t, prv.JSON("items", "", &items))
+	assert.Len(t, items, 2)
+	assert.Equal(t, "pen", items[1].Name)
+
+	drv.Set("items[2][id]", "9")
+	assert.Equal(t, []string{"7", "8", "9"}, drv.GetArray("items.#.id"))
+	drv.Del("tags[]")
+	assert.Nil(t, drv.GetArray("tags"))
+}
+
+func TestHTTPDriverRace(t *testing.T) {
+	var wg sync.WaitGroup
+
+	req, err := http.NewRequest("GET", "/?id=1&list=a&list=b&user[name]=x", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-Request-ID", "req")
+	req.SetPathValue("id", "1")
+
+	drv, err := envx.NewHTTPDriver(req)
+	assert.NoError(t, err)
+
+	// Исходный запрос может меняться, пока драйвер читают
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for j := 0; j < 100; j++ {
+			req.SetPathValue("id", strconv.Itoa(j))
+		}
+	}()
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				drv.Get("id")
+				drv.GetArray("list")
+				drv.Get("user.name")
+				drv.Get("header:x-request-id")
+				assert.Equal(t, "1", drv.Get("path:id"))
+				drv.Set("id", strconv.Itoa(j))
+				drv.Set("user[role]", "dev")
+				drv.Set("header:x-trace", "t")
+				drv.Del("header:x-trace")
+
+				if arr := drv.GetArray("list"); assert.Len(t, arr, 2) {
+					arr[0] = strconv.Itoa(i)
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	assert.Equal(t, []string{"a", "b"}, drv.GetArray("list"))
+	assert.Equal(t, "1", req.Form.Get("id"))
 }
 
 func TestDriverJSON(t *testing.T) {
@@ -129,6 +350,205 @@ func TestDriverJSON(t *testing.T) {
 	assert.Equal(t, good, drv.GetArray(want))
 }
 
+func TestDriverJSONPath(t *testing.T) {
+	drv := envx.NewDriverJSON([]byte(`{
+		"Владельцы": {
+			"Иванов": {
+				"Адреса": {
+					"дом": {"Город": "Москва"},
+					"дача": {"Город": "Истра"}
+				},
+				"Машины": [{"Номер": "А001АА"}, {"Номер": "В002ВВ"}]
+			},
+			"П. Лут": {
+				"Адреса": {
+					"дом": {"Город": "Усть-Каменогорск"}
+				},
+				"Машины": []
+			}
+		},
+		"a.b": {"$": "dollar", "#": "hash"}
+	}`))
+
+	assert.Equal(t, []string{"Москва", "Истра", "Усть-Каменогорск"}, drv.GetArray("Владельцы.$.Адреса.$.Город"))
+	assert.Equal(t, []string{"дом", "дача", "дом"}, drv.GetArray("Владельцы.$.Адреса.@"))
+	assert.Equal(t, []string{"А001АА", "В002ВВ"}, drv.GetArray("Владельцы.$.Машины.#.Номер"))
+	assert.Equal(t, []string{"2", "0"}, drv.GetArray("Владельцы.$.Машины.#"))
+	assert.Equal(t, []string{"Москва", "Истра", "Усть-Каменогорск"}, drv.GetArray("**.Город"))
+	assert.Equal(t, []string{"Москва", "Усть-Каменогорск"}, drv.GetArray("Владельцы.**.дом.Город"))
+	assert.Equal(t, []string{"dollar"}, drv.GetArray(`a\.b.\$`))
+	assert.Equal(t, []string{"hash"}, drv.GetArray(`a\.b.\#`))
+	assert.Equal(t, []string{}, drv.GetArray("Владельцы.П\\. Лут.Машины.#.Номер"))
+	assert.Equal(t, []string{}, drv.GetArray("Владельцы.$.Телефон"))
+	assert.Nil(t, drv.GetArray("Владельцы.Петров.Машины.#.Номер"))
+
+	list, err := envx.NewDriverJSON([]byte(jsBenchEvent)).(envx.JSONDriver).Select("**.ИдФайла")
+	assert.NoError(t, err)
+	assert.Len(t, list, 6)
+
+	for _, path := range []string{"", ".a", "a.", "a..b", "a.@.b", "a.**", "**.**.a", `a\`} {
+		list, err = drv.Select(path)
+		assert.Nil(t, list, path)
+		assert.True(t, errors.Is(err, envx.ErrPathInvalid), path)
+		assert.Nil(t, drv.GetArray(path), path)
+	}
+}
+
+func TestDriverJSONWrite(t *testing.T) {
+	envxtest.DriverConformance(t, func(t *testing.T) envx.Driver {
+		return envx.NewDriverJSON(nil)
+	}, envxtest.WithoutMultiValue())
+
+	testDriver(t, envx.NewDriverJSON(nil))
+
+	drv := envx.NewDriverJSON([]byte(`{"meow": ["purpur"]}`))
+
+	drv.Set("meow", "furfur")
+	drv.Set("тачки.0.модель", "vaz")
+	drv.Set("Владельцы.Иванов.Город", " Москва ")
+	assert.Equal(t, []string{"purpur", "furfur"}, drv.GetArray("meow"))
+	assert.Equal(t, "vaz", drv.Get("тачки.0.модель"))
+	assert.Equal(t, "Москва", drv.Get("Владельцы.Иванов.Город"))
+
+	drv.Del("meow")
+	assert.Nil(t, drv.GetArray("meow"))
+	assert.JSONEq(t, `{
+		"тачки": [{"модель": "vaz"}],
+		"Владельцы": {"Иванов": {"Город": "Москва"}}
+	}`, string(drv.Bytes()))
+
+	ro := envx.NewDriverJSONReadOnly([]byte(`{"test": "ololo"}`))
+
+	assert.NotPanics(t, func() {
+		ro.Set("test", "purpur")
+		ro.Del("test")
+	})
+	assert.Equal(t, "ololo", ro.Get("test"))
+
+	if err := ro.Update("test", "purpur"); assert.Error(t, err) {
+		assert.True(t, errors.Is(err, envx.ErrReadOnly))
+	}
+
+	if err := ro.Remove("test"); assert.Error(t, err) {
+		assert.True(t, errors.Is(err, envx.ErrReadOnly))
+	}
+
+	if err := drv.Update("", "purpur"); assert.Error(t, err) {
+		assert.True(t, errors.Is(err, envx.ErrPathInvalid))
+	}
+}
+
+func TestDriverJSONTyped(t *testing.T) {
+	drv := envx.NewDriverJSON([]byte(`{
+		"debug": false,
+		"verbose": 1,
+		"id": 9007199254740993,
+		"ratio": 0.25,
+		"name": "ololo",
+		"nested": "[1, 2]",
+		"list": [1, 2],
+		"dict": {"f1": "f2"},
+		"none": null
+	}`))
+
+	kinds := map[string]envx.Kind{
+		"debug":  envx.KindBool,
+		"id":     envx.KindNumber,
+		"name":   envx.KindString,
+		"list":   envx.KindArray,
+		"dict":   envx.KindObject,
+		"none":   envx.KindNull,
+		"nested": envx.KindString,
+	}
+
+	for key, kind := range kinds {
+		val, ok := drv.LookupValue(key)
+		assert.True(t, ok, key)
+		assert.Equal(t, kind, val.Kind, key)
+	}
+
+	_, ok := drv.LookupValue("missing")
+	assert.False(t, ok)
+
+	val, _ := drv.LookupValue("id")
+	num, ok := val.Int()
+	assert.True(t, ok)
+	assert.Equal(t, int64(9007199254740993), num)
+
+	val, _ = drv.LookupValue("ratio")
+	assert.Equal(t, 0.25, val.Float())
+	_, ok = val.Uint()
+	assert.False(t, ok)
+
+	val, _ = drv.LookupValue("dict")
+	assert.Equal(t, `{"f1": "f2"}`, val.Raw)
+
+	prv := envx.NewProvider(drv)
+	assert.False(t, prv.Bool("debug", true))
+	assert.True(t, prv.Bool("verbose", false))
+
+	id, err := prv.Uint64("id", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(9007199254740993), id)
+
+	_, err = prv.Uint64("ratio", 0)
+	assert.True(t, errors.Is(err, envx.ErrUint64Invalid))
+
+	var dict map[string]string
+	assert.NoError(t, prv.JSON("dict", "", &dict))
+	assert.Equal(t, map[string]string{"f1": "f2"}, dict)
+
+	var list []int
+	assert.NoError(t, prv.JSON("list", "", &list))
+	assert.Equal(t, []int{1, 2}, list)
+	assert.NoError(t, prv.JSON("nested", "", &list))
+	assert.Equal(t, []int{1, 2}, list)
+}
+
+func TestDriverJSONEach(t *testing.T) {
+	drv := envx.NewDriverJSON([]byte(jsBenchEvent))
+
+	var kinds, signs []string
+
+	drv.Each("Документы", func(i int, sub envx.Driver) bool {
+		prv := envx.NewProvider(sub)
+		kinds = append(kinds, prv.String("ТипДокумента", ""))
+		assert.False(t, prv.Bool("Зашифрован", true))
+
+		sub.(envx.JSONDriver).Each("Подписи", func(j int, sign envx.Driver) bool {
+			signs = append(signs, sign.Get("ИдФайла"))
+			return true
+		})
+
+		return i < 1
+	})
+
+	assert.Equal(t, []string{"документ", "приложение"}, kinds)
+	assert.Equal(t, []string{
+		"09e3968080fd447aa56954b34872f9f1/39d19ae2ddec4b98af57e60487c69a9c",
+		"09e3968080fd447aa56954b34872f9f1/024a426f619745f3a3ddb1a3ba9e2012",
+	}, signs)
+
+	doc := drv.Sub("Документы.2")
+	assert.Equal(t, "TR_INFSOOB.xml", doc.Get("Наименование"))
+
+	// Изменения поддерева не затрагивают исходный документ
+	doc.Set("Наименование", "ololo")
+	assert.Equal(t, "ololo", doc.Get("Наименование"))
+	assert.Equal(t, "TR_INFSOOB.xml", drv.Get("Документы.2.Наименование"))
+
+	var fields int
+
+	drv.Each("Документы.0", func(i int, sub envx.Driver) bool {
+		fields++
+		return true
+	})
+	assert.Equal(t, 6, fields)
+
+	assert.Equal(t, "", drv.Sub("missing").Get("test"))
+	assert.Equal(t, "", drv.Sub("Направление").Get("test"))
+}
+
 //nolint:lll
 const jsBenchEvent = `{
   "Направление": "ФНС",
@@ -0,0 +1,283 @@
+package envx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/shestakovda/errx"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+const (
+	xmlAttr  = "@"
+	xmlSpace = "http://www.w3.org/XML/1998/namespace"
+)
+
+// NewDriverXML - получение аргументов из XML-документа (только для чтения)
+//
+// Путь строится так же, как для JSON-драйвера, с поправками на XML:
+//
+// * Первый сегмент - имя корневого элемента
+// * Имя без префикса совпадает с элементом или атрибутом из любого пространства имен
+// * Имя с префиксом `xsi:Ид` совпадает только в пространстве имен, объявленном для префикса в корневом элементе
+// * Имя `{urn:x}Ид` совпадает в пространстве имен `urn:x` независимо от префиксов, точки в нем экранируются: `{urn:x\.y}Ид`
+// * Сегмент с именем элемента выбирает все дочерние элементы с этим именем, `#` в середине пути ничего не меняет
+// * Числовой сегмент выбирает элемент из уже найденных по порядку: `Файл.Документ.1`
+// * `@Имя` последним сегментом - значение атрибута, `@` - имена атрибутов
+// * `$` - все дочерние элементы, `**` - любая глубина вложенности, `#` последним сегментом - количество
+// * Значение элемента - его текст без пробелов по краям
+func NewDriverXML(src []byte) (_ XMLDriver, err error) {
+	drv := &xmlDriver{
+		doc: new(xmlNode),
+		ns:  map[string]string{"xml": xmlSpace},
+	}
+
+	if err = drv.parse(src); err != nil {
+		return nil, err
+	}
+
+	return drv, nil
+}
+
+// Документ доступен только для чтения, поэтому блокировки не нужны
+type xmlDriver struct {
+	doc *xmlNode
+	ns  map[string]string // префикс - пространство имен по объявлениям корневого элемента
+}
+
+type xmlNode struct {
+	name  xml.Name
+	text  string
+	attrs []xml.Attr
+	nodes []*xmlNode
+}
+
+// xmlName - имя из пути, без префикса совпадает в любом пространстве имен
+type xmlName struct {
+	space string
+	local string
+	any   bool
+}
+
+func (n *xmlName) match(name xml.Name) bool {
+	return n == nil || name.Local == n.local && (n.any || name.Space == n.space)
+}
+
+func (d *xmlDriver) Set(name, value string) { _ = d.Update(name, value) }
+func (d *xmlDriver) Del(name string)        { _ = d.Remove(name) }
+
+func (d *xmlDriver) Update(name, value string) error {
+	return ErrReadOnly.WithDebug(errx.Debug{argName: name})
+}
+
+func (d *xmlDriver) Remove(name string) error {
+	return ErrReadOnly.WithDebug(errx.Debug{argName: name})
+}
+
+func (d *xmlDriver) Get(name string) string {
+	if list := d.GetArray(name); len(list) > 0 {
+		return list[0]
+	}
+
+	return ""
+}
+
+func (d *xmlDriver) GetArray(name string) []string {
+	path, err := parsePath(name)
+
+	if err != nil {
+		return nil
+	}
+
+	return d.eval(path, []*xmlNode{d.doc})
+}
+
+func (d *xmlDriver) eval(path jsonPath, cur []*xmlNode) []string {
+	for i, seg := range path {
+		last := i == len(path)-1
+
+		switch seg.kind {
+		case kindKey:
+			if strings.HasPrefix(seg.key, xmlAttr) {
+				if !last {
+					return nil
+				}
+
+				return xmlAttrs(cur, d.name(strings.TrimPrefix(seg.key, xmlAttr)))
+			}
+
+			if num, err := strconv.Atoi(seg.key); err == nil {
+				if num < 0 || num >= len(cur) {
+					return nil
+				}
+
+				cur = cur[num : num+1]
+				continue
+			}
+
+			cur = xmlChildren(cur, d.name(seg.key))
+		case kindAll:
+			if last {
+				return []string{strconv.Itoa(len(cur))}
+			}
+		case kindVals:
+			cur = xmlChildren(cur, nil)
+		case kindKeys:
+			return xmlAttrs(cur, nil)
+		case kindDeep:
+			cur = xmlDeep(cur, nil)
+		}
+
+		if len(cur) == 0 {
+			return nil
+		}
+	}
+
+	list := make([]string, len(cur))
+
+	for i := range cur {
+		list[i] = cur[i].text
+	}
+
+	return list
+}
+
+// name - имя из пути, префикс заменяется пространством имен из корневого элемента
+//
+// Необъявленный префикс остается как есть, так же его оставляет и разбор документа
+func (d *xmlDriver) name(name string) *xmlName {
+	if strings.HasPrefix(name, "{") {
+		if end := strings.IndexByte(name, '}'); end > 0 {
+			return &xmlName{space: name[1:end], local: name[end+1:]}
+		}
+	}
+
+	pos := strings.LastIndexByte(name, ':')
+
+	if pos < 0 {
+		return &xmlName{local: name, any: true}
+	}
+
+	space, ok := d.ns[name[:pos]]
+
+	if !ok {
+		space = name[:pos]
+	}
+
+	return &xmlName{space: space, local: name[pos+1:]}
+}
+
+// xmlChildren - дочерние элементы с указанным именем или все, если имя не задано
+func xmlChildren(cur []*xmlNode, name *xmlName) []*xmlNode {
+	var res []*xmlNode
+
+	for i := range cur {
+		for _, node := range cur[i].nodes {
+			if name.match(node.name) {
+				res = append(res, node)
+			}
+		}
+	}
+
+	return res
+}
+
+// xmlDeep - сами элементы и все их потомки
+func xmlDeep(cur, res []*xmlNode) []*xmlNode {
+	for i := range cur {
+		res = xmlDeep(cur[i].nodes, append(res, cur[i]))
+	}
+
+	return res
+}
+
+// xmlAttrs - значения атрибута с указанным именем или имена всех атрибутов, если имя не задано
+func xmlAttrs(cur []*xmlNode, name *xmlName) []string {
+	var res []string
+
+	for i := range cur {
+		for _, attr := range cur[i].attrs {
+			switch {
+			case name == nil:
+				res = append(res, attr.Name.Local)
+			case name.match(attr.Name):
+				res = append(res, attr.Value)
+			}
+		}
+	}
+
+	return res
+}
+
+// parse - разбор документа с запоминанием префиксов корневого элемента
+//
+// Пространства имен элементов и атрибутов разрешает сам xml.Decoder с учетом области видимости
+// каждого объявления, поэтому переобъявленный во вложенном элементе префикс на них не влияет
+func (d *xmlDriver) parse(src []byte) (err error) {
+	var root *xmlNode
+	var tok xml.Token
+	var stack []*xmlNode
+	var text []*strings.Builder
+
+	dec := xml.NewDecoder(bytes.NewReader(src))
+	dec.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		enc, err := htmlindex.Get(label)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return enc.NewDecoder().Reader(input), nil
+	}
+
+	for {
+		if tok, err = dec.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			return ErrXMLInvalid.WithReason(err).WithDebug(errx.Debug{"Смещение": dec.InputOffset()})
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name}
+
+			for _, attr := range t.Attr {
+				switch {
+				case attr.Name.Space == "xmlns":
+					if root == nil {
+						d.ns[attr.Name.Local] = attr.Value
+					}
+				case attr.Name.Local != "xmlns" || attr.Name.Space != "":
+					node.attrs = append(node.attrs, attr)
+				}
+			}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.nodes = append(parent.nodes, node)
+			} else if root == nil {
+				root = node
+			}
+
+			stack = append(stack, node)
+			text = append(text, new(strings.Builder))
+		case xml.CharData:
+			if len(text) > 0 {
+				text[len(text)-1].Write(t)
+			}
+		case xml.EndElement:
+			stack[len(stack)-1].text = strings.TrimSpace(text[len(text)-1].String())
+			stack, text = stack[:len(stack)-1], text[:len(text)-1]
+		}
+	}
+
+	if root == nil {
+		return ErrXMLInvalid.WithDetail("Документ не содержит элементов")
+	}
+
+	d.doc.nodes = []*xmlNode{root}
+	return nil
+}
@@ -0,0 +1,102 @@
+package envx_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shestakovda/envx"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const xmlBenchFile = `<?xml version="1.0" encoding="windows-1251"?>
+<Файл xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" ИдФайл="ON_DOCNPNO_9653460472" ВерсФорм="5.01">
+	<Документ КНД="1165050" ДатаДок="2020-03-05T10:00:00Z" xsi:Ид="bc3a424b-a27c-4f6d-9ba3-973d5b6f3c69">
+		<СвНП>
+			<НПЮЛ ИННЮЛ="9653460472" КПП="999901001"/>
+		</СвНП>
+		<Подписант> Иванов </Подписант>
+	</Документ>
+	<Документ КНД="1165051">
+		<Подписант>Петров В.</Подписант>
+	</Документ>
+</Файл>`
+
+func TestDriverXML(t *testing.T) {
+	src, err := charmap.Windows1251.NewEncoder().Bytes([]byte(xmlBenchFile))
+	assert.NoError(t, err)
+
+	drv, err := envx.NewDriverXML(src)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "5.01", drv.Get("Файл.@ВерсФорм"))
+	assert.Equal(t, "1165050", drv.Get("Файл.Документ.@КНД"))
+	assert.Equal(t, []string{"1165050", "1165051"}, drv.GetArray("Файл.Документ.@КНД"))
+	assert.Equal(t, []string{"1165050", "1165051"}, drv.GetArray("Файл.Документ.#.@КНД"))
+	assert.Equal(t, "1165051", drv.Get("Файл.Документ.1.@КНД"))
+	assert.Equal(t, "2", drv.Get("Файл.Документ.#"))
+	assert.Equal(t, []string{"Иванов", "Петров В."}, drv.GetArray("Файл.Документ.Подписант"))
+	assert.Equal(t, []string{"ИННЮЛ", "КПП"}, drv.GetArray("**.НПЮЛ.@"))
+	assert.Equal(t, []string{"9653460472"}, drv.GetArray("**.@ИННЮЛ"))
+	assert.Equal(t, []string{"", "Иванов", "Петров В."}, drv.GetArray("Файл.Документ.$"))
+	assert.Nil(t, drv.GetArray("Файл.Документ.5.@КНД"))
+	assert.Nil(t, drv.GetArray("Корень"))
+	assert.Nil(t, drv.GetArray("Файл..Документ"))
+
+	// Пространства имен не влияют на поиск
+	assert.Equal(t, "bc3a424b-a27c-4f6d-9ba3-973d5b6f3c69", drv.Get("Файл.Документ.@xsi:Ид"))
+
+	// Префиксы сравниваются по пространствам имен, а не по написанию
+	ns, err := envx.NewDriverXML([]byte(`<r xmlns:a="urn:a" xmlns:b="urn:b" xmlns:c="urn:a">
+		<a:x>1</a:x><b:x>2</b:x><x>3</x><b:y a:k="4" b:k="5"/>
+	</r>`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, ns.GetArray("r.x"))
+	assert.Equal(t, []string{"1"}, ns.GetArray("r.a:x"))
+	assert.Equal(t, []string{"1"}, ns.GetArray("r.c:x"))
+	assert.Equal(t, []string{"2"}, ns.GetArray("r.b:x"))
+	assert.Equal(t, []string{"5"}, ns.GetArray("r.y.@b:k"))
+	assert.Nil(t, ns.GetArray("r.d:x"))
+
+	// Переобъявленный во вложенном элементе префикс действует только внутри него,
+	// префиксы пути берутся из корня, а любое пространство имен доступно через `{uri}`
+	redecl, err := envx.NewDriverXML([]byte(`<r xmlns:a="http://example.com/a">
+		<a:x>1</a:x><s xmlns:a="urn:b"><a:x a:k="3">2</a:x></s><a:x>4</a:x>
+	</r>`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "4"}, redecl.GetArray("r.a:x"))
+	assert.Nil(t, redecl.GetArray("r.s.a:x"))
+	assert.Equal(t, []string{"2"}, redecl.GetArray("r.s.x"))
+	assert.Equal(t, []string{"2"}, redecl.GetArray("r.s.{urn:b}x"))
+	assert.Equal(t, []string{"3"}, redecl.GetArray("r.s.x.@{urn:b}k"))
+	assert.Equal(t, []string{"1", "4"}, redecl.GetArray("**.a:x"))
+	assert.Equal(t, []string{"1", "4"}, redecl.GetArray(`r.{http://example\.com/a}x`))
+
+	// Запись не поддерживается: Set и Del ничего не делают, Update и Remove возвращают ошибку
+	drv.Set("Файл.@ВерсФорм", "6")
+	drv.Del("Файл.@ВерсФорм")
+	assert.Equal(t, "5.01", drv.Get("Файл.@ВерсФорм"))
+	assert.True(t, errors.Is(drv.Update("Файл.@ВерсФорм", "6"), envx.ErrReadOnly))
+	assert.True(t, errors.Is(drv.Remove("Файл.@ВерсФорм"), envx.ErrReadOnly))
+
+	prv := envx.NewProvider(drv)
+
+	guid, err := prv.GUID("Файл.Документ.@Ид", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "BC3A424B-A27C-4F6D-9BA3-973D5B6F3C69", guid)
+
+	date, err := prv.TimeRFC3339("Файл.Документ.@ДатаДок", time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2020, date.Year())
+
+	num, err := prv.Uint64("Файл.Документ.СвНП.НПЮЛ.@ИННЮЛ", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(9653460472), num)
+
+	_, err = envx.NewDriverXML([]byte(`<Файл><Документ></Файл>`))
+	assert.True(t, errors.Is(err, envx.ErrXMLInvalid))
+
+	_, err = envx.NewDriverXML([]byte(` `))
+	assert.True(t, errors.Is(err, envx.ErrXMLInvalid))
+}
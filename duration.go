@@ -0,0 +1,267 @@
+package envx
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/shestakovda/errx"
+)
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+)
+
+// Единицы промежутков времени, кроме стандартных Go добавлены дни и недели
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"μs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  day,
+	"w":  week,
+}
+
+// parseDuration - разбор промежутка времени
+//
+// * Стандартный формат Go: `1h30m`, `250ms`
+// * Дни и недели, в т.ч. дробные и через пробел: `30d`, `2w`, `1d 2h`, `1.5d`
+// * ISO 8601: `P1DT12H`, `PT0.5S`, `P2W`, годы и месяцы не поддерживаются из-за разной длины
+// * Вне формата Go каждая единица указывается не больше одного раза, сумма считается в целых наносекундах
+func parseDuration(s string) (time.Duration, errx.Error) {
+	if dur, err := time.ParseDuration(s); err == nil {
+		return dur, nil
+	}
+
+	neg, rest := false, s
+
+	if strings.HasPrefix(rest, "-") || strings.HasPrefix(rest, "+") {
+		neg, rest = rest[0] == '-', rest[1:]
+	}
+
+	if strings.HasPrefix(rest, "p") {
+		return parseISODuration(neg, rest[1:])
+	}
+
+	return parseHumanDuration(neg, rest)
+}
+
+func parseHumanDuration(neg bool, s string) (time.Duration, errx.Error) {
+	sum := durationSum{neg: neg, seen: make(map[time.Duration]bool, 4)}
+	s = strings.Join(strings.Fields(s), "")
+
+	if s == "" {
+		return 0, ErrDurationInvalid.WithDetail("Пустое значение")
+	}
+
+	for s != "" {
+		num, unit, rest, err := durationPart(s)
+
+		if err != nil {
+			return 0, err
+		}
+
+		mul, ok := durationUnits[unit]
+
+		if !ok {
+			return 0, ErrDurationInvalid.WithDetail("Неизвестная единица `%s`", unit)
+		}
+
+		if err = sum.add(num, unit, mul); err != nil {
+			return 0, err
+		}
+
+		s = rest
+	}
+
+	return sum.result(), nil
+}
+
+func parseISODuration(neg bool, s string) (time.Duration, errx.Error) {
+	var clock, timed bool
+
+	sum := durationSum{neg: neg, seen: make(map[time.Duration]bool, 4)}
+
+	if s == "" {
+		return 0, ErrDurationInvalid.WithDetail("Пустой промежуток ISO 8601")
+	}
+
+	for s != "" {
+		if s[0] == 't' {
+			if clock {
+				return 0, ErrDurationInvalid.WithDetail("Повторный разделитель `T`")
+			}
+
+			clock, s = true, s[1:]
+			continue
+		}
+
+		num, unit, rest, err := durationPart(s)
+
+		if err != nil {
+			return 0, err
+		}
+
+		var mul time.Duration
+
+		switch {
+		case !clock && unit == "w":
+			mul = week
+		case !clock && unit == "d":
+			mul = day
+		case !clock && (unit == "y" || unit == "m"):
+			return 0, ErrDurationInvalid.WithDetail("Годы и месяцы не поддерживаются")
+		case clock && unit == "h":
+			mul = time.Hour
+		case clock && unit == "m":
+			mul = time.Minute
+		case clock && unit == "s":
+			mul = time.Second
+		default:
+			return 0, ErrDurationInvalid.WithDetail("Неизвестная единица `%s`", unit)
+		}
+
+		if err = sum.add(num, unit, mul); err != nil {
+			return 0, err
+		}
+
+		timed, s = clock, rest
+	}
+
+	if clock && !timed {
+		return 0, ErrDurationInvalid.WithDetail("После разделителя `T` нет времени")
+	}
+
+	return sum.result(), nil
+}
+
+// durationNum - неотрицательное число с дробной частью: whole + frac / scale
+type durationNum struct {
+	whole uint64
+	frac  uint64
+	scale uint64
+}
+
+// durationPart - число и единица в начале строки, дробная часть отделяется точкой или запятой
+func durationPart(s string) (num durationNum, unit, rest string, err errx.Error) {
+	pos := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' && r != ',' })
+
+	if pos < 0 {
+		pos = len(s)
+	}
+
+	if num, err = parseDurationNum(s[:pos]); err != nil {
+		return num, "", "", err
+	}
+
+	end := strings.IndexFunc(s[pos:], func(r rune) bool { return (r >= '0' && r <= '9') || r == 't' })
+
+	if end < 0 {
+		end = len(s) - pos
+	}
+
+	return num, s[pos : pos+end], s[pos+end:], nil
+}
+
+// parseDurationNum - разбор числа без float, лишние знаки дробной части отбрасываются
+func parseDurationNum(s string) (num durationNum, err errx.Error) {
+	var digits, dot bool
+
+	num.scale = 1
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '.' || c == ',':
+			if dot {
+				return num, ErrDurationInvalid.WithDetail("Некорректное число `%s`", s)
+			}
+
+			dot = true
+		case dot:
+			digits = true
+
+			// Наносекунды - наименьшая единица, больше 18 знаков дроби ничего не меняют
+			if num.scale < 1e18 {
+				num.frac, num.scale = num.frac*10+uint64(c-'0'), num.scale*10
+			}
+		default:
+			digits = true
+
+			if num.whole > (math.MaxUint64-9)/10 {
+				return num, ErrDurationInvalid.WithDetail("Слишком большой промежуток")
+			}
+
+			num.whole = num.whole*10 + uint64(c-'0')
+		}
+	}
+
+	if !digits {
+		return num, ErrDurationInvalid.WithDetail("Ожидается число в начале `%s`", s)
+	}
+
+	return num, nil
+}
+
+// durationSum - сумма частей промежутка в наносекундах с проверкой переполнения
+type durationSum struct {
+	neg  bool
+	sum  uint64
+	seen map[time.Duration]bool
+}
+
+func (d *durationSum) add(num durationNum, unit string, mul time.Duration) errx.Error {
+	if d.seen[mul] {
+		return ErrDurationInvalid.WithDetail("Повторная единица `%s`", unit)
+	}
+
+	d.seen[mul] = true
+
+	// Модуль отрицательного промежутка может быть на единицу больше
+	limit := uint64(math.MaxInt64)
+
+	if d.neg {
+		limit++
+	}
+
+	if num.whole > limit/uint64(mul) {
+		return ErrDurationInvalid.WithDetail("Слишком большой промежуток")
+	}
+
+	val := num.whole * uint64(mul)
+
+	if num.frac > 0 {
+		val += uint64(math.Round(float64(num.frac) * (float64(mul) / float64(num.scale))))
+	}
+
+	if val > limit || d.sum > limit-val {
+		return ErrDurationInvalid.WithDetail("Слишком большой промежуток")
+	}
+
+	d.sum += val
+	return nil
+}
+
+func (d *durationSum) result() time.Duration {
+	if d.neg {
+		return time.Duration(-d.sum)
+	}
+
+	return time.Duration(d.sum)
+}
+
+// durationRange - проверка попадания промежутка в границы, нулевой max - без верхней границы
+func durationRange(name string, dur, min, max time.Duration) errx.Error {
+	if dur < min || (max > 0 && dur > max) {
+		return ErrDurationInvalid.WithDetail("Значение вне диапазона от %s до %s", min, max).WithDebug(errx.Debug{
+			argValue: dur.String(),
+			argName:  name,
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package envx
+
+import (
+	"sort"
+	"strings"
+)
+
+// enumKeys - отсортированный список ключей снимка с указанным префиксом
+func enumKeys(items map[string][]string, prefix string) []string {
+	keys := make([]string, 0, len(items))
+
+	for key := range items {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// enumRange - обход снимка в порядке сортировки ключей
+func enumRange(items map[string][]string, fn func(name string, values []string) bool) {
+	keys := enumKeys(items, "")
+
+	for i := range keys {
+		if !fn(keys[i], items[keys[i]]) {
+			return
+		}
+	}
+}
+
+// enumDriver - перечисление ключей драйвера, если он это поддерживает
+func enumDriver(drv Driver) Enumerable {
+	if enum, ok := drv.(Enumerable); ok {
+		return enum
+	}
+
+	return nil
+}
+
+// trimValues - копия списка значений без пробелов по краям
+func trimValues(values []string) []string {
+	res := make([]string, len(values))
+
+	for i := range values {
+		res[i] = strings.TrimSpace(values[i])
+	}
+
+	return res
+}
@@ -0,0 +1,77 @@
+package envx_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/shestakovda/envx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnumerable(t *testing.T) {
+	mem := envx.NewMemDriver(16)
+	mem.Set("db.port", " 5432 ")
+	mem.Set("db.host", "localhost")
+	mem.Set("name", "app")
+
+	enum := mem.(envx.Enumerable)
+	assert.Equal(t, []string{"db.host", "db.port", "name"}, enum.Keys(""))
+	assert.Equal(t, []string{"db.host", "db.port"}, enum.Keys("db."))
+
+	var seen []string
+
+	enum.Range(func(name string, values []string) bool {
+		seen = append(seen, name+"="+values[0])
+		return len(seen) < 2
+	})
+	assert.Equal(t, []string{"db.host=localhost", "db.port=5432"}, seen)
+
+	env := envx.NewEnvDriver("enumtest")
+	env.Set("b", " 2 ")
+	env.Set("a", "1")
+	defer env.Del("a")
+	defer env.Del("b")
+
+	assert.Equal(t, []string{"A", "B"}, env.(envx.Enumerable).Keys(""))
+	assert.Equal(t, []string{"B"}, env.(envx.Enumerable).Keys("b"))
+
+	// Префикс дампа нормализуется так же, как в Keys
+	dump := envx.NewProvider(env).Dump("b")
+	assert.Len(t, dump, 1)
+	assert.Contains(t, dump, "B")
+
+	req, err := http.NewRequest("GET", "/?tag=+a&tag=b+&id=7", nil)
+	assert.NoError(t, err)
+
+	drv, err := envx.NewHTTPDriver(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "tag"}, drv.(envx.Enumerable).Keys(""))
+	assert.Equal(t, map[string][]string{
+		"id":  {"7"},
+		"tag": {"a", "b"},
+	}, envx.NewProvider(drv).Dump(""))
+	assert.Equal(t, []string{" a", "b "}, req.Form["tag"])
+
+	js := envx.NewDriverJSON([]byte(`{
+		"test": "ololo",
+		"meow": ["purpur", "furfur"],
+		"тачки": [{"модель": "vaz", "год": 1995}, {"модель": "gaz"}],
+		"a.b": {"c": null}
+	}`))
+
+	assert.Equal(t, []string{
+		`a\.b.c`, "meow", "test", "тачки.0.год", "тачки.0.модель", "тачки.1.модель",
+	}, js.(envx.Enumerable).Keys(""))
+
+	prv := envx.NewProvider(js)
+	assert.Equal(t, []string{"тачки.0.год", "тачки.0.модель", "тачки.1.модель"}, prv.Keys("тачки."))
+
+	for key, values := range prv.Dump("") {
+		assert.Equal(t, js.GetArray(key), values, key)
+	}
+
+	// Обертки пробрасывают перечисление, а драйверы без него дают пустой результат
+	assert.Equal(t, []string{"name"}, envx.NewProvider(mem, envx.WithHooks(envx.Hooks{})).Keys("n"))
+	assert.Nil(t, envx.NewProvider(&countDriver{Driver: mem}).Keys(""))
+	assert.Nil(t, envx.NewProvider(&countDriver{Driver: mem}).Dump(""))
+}
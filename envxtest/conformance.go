@@ -0,0 +1,155 @@
+package envxtest
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/shestakovda/envx"
+)
+
+const (
+	confKey   = "conformance_key"
+	confValue = " conformance value "
+	confTrim  = "conformance value"
+	confLoops = 100
+	confProcs = 8
+)
+
+// Option - настройка проверки соответствия контракту
+type Option func(c *config)
+
+type config struct {
+	multi bool
+	race  bool
+}
+
+// WithoutMultiValue - драйвер хранит только одно значение по ключу, Set его заменяет
+func WithoutMultiValue() Option {
+	return func(c *config) { c.multi = false }
+}
+
+// WithoutConcurrency - пропуск проверки одновременного доступа
+func WithoutConcurrency() Option {
+	return func(c *config) { c.race = false }
+}
+
+// DriverConformance - проверка соответствия драйвера контракту envx.Driver
+//
+// * factory вызывается для каждой проверки и должна возвращать драйвер без значения conformance_key
+// * Одновременный доступ имеет смысл проверять с флагом -race
+func DriverConformance(t *testing.T, factory func(t *testing.T) envx.Driver, opts ...Option) {
+	c := &config{
+		multi: true,
+		race:  true,
+	}
+
+	for i := range opts {
+		opts[i](c)
+	}
+
+	t.Run("Missing", func(t *testing.T) {
+		d := factory(t)
+		expect(t, "Get", "", d.Get(confKey))
+		expect(t, "GetArray", []string(nil), d.GetArray(confKey))
+
+		_, ok := envx.Lookup(d, confKey)
+		expect(t, "Lookup", false, ok)
+	})
+
+	t.Run("Trim", func(t *testing.T) {
+		d := factory(t)
+		d.Set(confKey, confValue)
+		expect(t, "Get", confTrim, d.Get(confKey))
+		expect(t, "GetArray", []string{confTrim}, d.GetArray(confKey))
+
+		s, ok := envx.Lookup(d, confKey)
+		expect(t, "Lookup", true, ok)
+		expect(t, "Lookup", confTrim, s)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		d := factory(t)
+		d.Set(confKey, "")
+		expect(t, "Get", "", d.Get(confKey))
+		expect(t, "GetArray (empty value must differ from missing one)", []string{""}, d.GetArray(confKey))
+
+		s, ok := envx.Lookup(d, confKey)
+		expect(t, "Lookup", true, ok)
+		expect(t, "Lookup", "", s)
+	})
+
+	t.Run("MultiValue", func(t *testing.T) {
+		d := factory(t)
+		d.Set(confKey, "first")
+		d.Set(confKey, "second")
+
+		if !c.multi {
+			expect(t, "Get", "second", d.Get(confKey))
+			expect(t, "GetArray", []string{"second"}, d.GetArray(confKey))
+			return
+		}
+
+		expect(t, "Get", "first", d.Get(confKey))
+		expect(t, "GetArray", []string{"first", "second"}, d.GetArray(confKey))
+	})
+
+	t.Run("Del", func(t *testing.T) {
+		d := factory(t)
+		d.Set(confKey, "first")
+		d.Set(confKey, "second")
+		d.Del(confKey)
+		expect(t, "Get", "", d.Get(confKey))
+		expect(t, "GetArray", []string(nil), d.GetArray(confKey))
+
+		// Удаление отсутствующего ключа - не ошибка
+		d.Del(confKey)
+	})
+
+	t.Run("Concurrency", func(t *testing.T) {
+		if !c.race {
+			t.Skip("concurrency check is disabled")
+		}
+
+		var wg sync.WaitGroup
+
+		d := factory(t)
+
+		for i := 0; i < confProcs; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				own := confKey + "_" + strconv.Itoa(i)
+
+				for j := 0; j < confLoops; j++ {
+					d.Set(confKey, confValue)
+					d.Set(own, confValue)
+					d.Get(confKey)
+					d.GetArray(confKey)
+
+					if list := d.GetArray(own); len(list) == 0 {
+						t.Errorf("GetArray(%q): value is missing", own)
+					} else {
+						expect(t, "GetArray", confTrim, list[0])
+					}
+
+					d.Del(own)
+				}
+			}(i)
+		}
+
+		wg.Wait()
+		d.Del(confKey)
+	})
+}
+
+// expect - сравнение с ожидаемым значением, nil и пустой список различаются
+func expect(t testing.TB, what string, want, got interface{}) {
+	t.Helper()
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("%s: want %#v, got %#v", what, want, got)
+	}
+}
@@ -0,0 +1,127 @@
+// Package envxtest - помощники для тестов кода, использующего envx
+package envxtest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/shestakovda/envx"
+)
+
+// Override - установка значений на время теста, прежние значения возвращаются в t.Cleanup
+//
+// * Драйвер с Restorable возвращает значения как были, вместе с пробелами и пустыми списками
+// * Остальным драйверам прежние значения возвращаются через Del и Set в том виде, в каком их вернул GetArray
+func Override(t testing.TB, d envx.Driver, kv map[string]string) {
+	t.Helper()
+
+	names := make([]string, 0, len(kv))
+
+	for name := range kv {
+		names = append(names, name)
+	}
+
+	if r, ok := d.(envx.Restorable); ok {
+		snap := r.Snapshot(names...)
+		t.Cleanup(func() { r.Restore(snap) })
+	} else {
+		prev := make(map[string][]string, len(kv))
+
+		for _, name := range names {
+			prev[name] = d.GetArray(name)
+		}
+
+		t.Cleanup(func() {
+			for name, values := range prev {
+				d.Del(name)
+
+				for i := range values {
+					d.Set(name, values[i])
+				}
+			}
+		})
+	}
+
+	for name, value := range kv {
+		d.Del(name)
+		d.Set(name, value)
+	}
+}
+
+// Методы драйвера в журнале вызовов
+const (
+	MethodGet      = "Get"
+	MethodGetArray = "GetArray"
+	MethodSet      = "Set"
+	MethodDel      = "Del"
+)
+
+// Call - вызов метода драйвера
+type Call struct {
+	Method string
+	Name   string
+	Value  string
+}
+
+// Fake - драйвер в памяти, записывающий все вызовы Get, GetArray, Set и Del
+type Fake struct {
+	envx.MemDriver
+	mu    sync.Mutex
+	calls []Call
+}
+
+// NewFake - драйвер с начальными значениями, начальная загрузка не записывается
+func NewFake(kv map[string]string) *Fake {
+	f := &Fake{
+		MemDriver: envx.NewMemDriver(uint(len(kv))),
+	}
+
+	for name, value := range kv {
+		f.MemDriver.Set(name, value)
+	}
+
+	return f
+}
+
+func (f *Fake) Get(name string) string {
+	f.record(MethodGet, name, "")
+	return f.MemDriver.Get(name)
+}
+
+func (f *Fake) GetArray(name string) []string {
+	f.record(MethodGetArray, name, "")
+	return f.MemDriver.GetArray(name)
+}
+
+func (f *Fake) Set(name, value string) {
+	f.record(MethodSet, name, value)
+	f.MemDriver.Set(name, value)
+}
+
+func (f *Fake) Del(name string) {
+	f.record(MethodDel, name, "")
+	f.MemDriver.Del(name)
+}
+
+// Calls - копия журнала вызовов
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append(make([]Call, 0, len(f.calls)), f.calls...)
+}
+
+// Reset - очистка журнала вызовов
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = nil
+}
+
+func (f *Fake) record(method, name, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, Call{Method: method, Name: name, Value: value})
+}
@@ -0,0 +1,76 @@
+package envxtest_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/shestakovda/envx"
+	"github.com/shestakovda/envx/envxtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverride(t *testing.T) {
+	drv := envx.NewMemDriver(4)
+	drv.Set("multi", "a")
+	drv.Set("multi", "b")
+
+	t.Run("scope", func(t *testing.T) {
+		envxtest.Override(t, drv, map[string]string{"multi": "c", "new": "d"})
+		assert.Equal(t, []string{"c"}, drv.GetArray("multi"))
+		assert.Equal(t, "d", drv.Get("new"))
+	})
+
+	assert.Equal(t, []string{"a", "b"}, drv.GetArray("multi"))
+	assert.Nil(t, drv.GetArray("new"))
+
+	// Значения возвращаются как были, вместе с пробелами и пустыми списками
+	drv.Replace("raw", " x ")
+	drv.SetArray("none", []string{})
+
+	t.Run("raw", func(t *testing.T) {
+		envxtest.Override(t, drv, map[string]string{"raw": "y", "none": "z"})
+		assert.Equal(t, "z", drv.Get("none"))
+	})
+
+	assert.Equal(t, map[string][]string{"raw": {" x "}, "none": {}}, drv.Snapshot("raw", "none"))
+
+	env := envx.NewEnvDriver("envxtest")
+	env.Set("keep", " 1 ")
+	defer env.Del("keep")
+
+	t.Run("env", func(t *testing.T) {
+		envxtest.Override(t, env, map[string]string{"keep": "2", "temp": "3"})
+		assert.Equal(t, "2", env.Get("keep"))
+		assert.Equal(t, "3", env.Get("temp"))
+	})
+
+	keep, ok := os.LookupEnv("ENVXTEST_KEEP")
+	assert.True(t, ok)
+	assert.Equal(t, " 1 ", keep)
+
+	_, ok = os.LookupEnv("ENVXTEST_TEMP")
+	assert.False(t, ok)
+}
+
+func TestFake(t *testing.T) {
+	envxtest.DriverConformance(t, func(t *testing.T) envx.Driver {
+		return envxtest.NewFake(nil)
+	})
+
+	drv := envxtest.NewFake(map[string]string{"port": " 8080 "})
+	assert.Empty(t, drv.Calls())
+
+	prv := envx.NewProvider(drv)
+	port, err := prv.Uint64("port", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(8080), port)
+	assert.NotEmpty(t, drv.Calls())
+
+	drv.Reset()
+	drv.Set("name", "envx")
+	drv.Del("name")
+	assert.Equal(t, []envxtest.Call{
+		{Method: envxtest.MethodSet, Name: "name", Value: "envx"},
+		{Method: envxtest.MethodDel, Name: "name"},
+	}, drv.Calls())
+}
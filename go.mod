@@ -1,10 +1,22 @@
 module github.com/shestakovda/envx
 
-go 1.13
+go 1.22
 
 require (
 	github.com/asaskevich/govalidator v0.0.0-20200907205600-7a23bdc65eef
 	github.com/shestakovda/errx v1.1.0
 	github.com/stretchr/testify v1.5.1
 	github.com/tidwall/gjson v1.6.1
+	github.com/tidwall/sjson v1.1.2
+	golang.org/x/text v0.14.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/kr/pretty v0.2.1 // indirect
+	github.com/kr/text v0.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/tidwall/match v1.0.1 // indirect
+	github.com/tidwall/pretty v1.0.2 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
 )
@@ -0,0 +1,118 @@
+package envx
+
+import "sync"
+
+// Hooks - обработчики событий доступа к параметрам, любой из них может быть пустым
+type Hooks struct {
+	// OnGet - чтение значения через Get или GetArray
+	OnGet func(name string, values []string)
+
+	// OnSet - установка значения, old - значение до изменения
+	OnSet func(name, old, value string)
+
+	// OnDel - удаление значения, old - значение до удаления
+	OnDel func(name, old string)
+
+	// OnDefault - провайдер подставил значение по-умолчанию
+	OnDefault func(name string, def interface{})
+
+	// OnError - провайдер вернул ошибку
+	OnError func(name string, err error)
+}
+
+// NewHookDriver - вызов обработчиков при каждом обращении к другому драйверу
+func NewHookDriver(drv Driver, hooks Hooks) Driver {
+	return &hookDriver{
+		drv:   drv,
+		hooks: hooks,
+	}
+}
+
+type hookDriver struct {
+	sync.Mutex
+	drv   Driver
+	hooks Hooks
+}
+
+func (d *hookDriver) Get(name string) string {
+	val := d.drv.Get(name)
+
+	if d.hooks.OnGet != nil {
+		d.hooks.OnGet(name, []string{val})
+	}
+
+	return val
+}
+
+func (d *hookDriver) GetArray(name string) []string {
+	arr := d.drv.GetArray(name)
+
+	if d.hooks.OnGet != nil {
+		d.hooks.OnGet(name, arr)
+	}
+
+	return arr
+}
+
+func (d *hookDriver) Set(name, value string) {
+	if d.hooks.OnSet == nil {
+		d.drv.Set(name, value)
+		return
+	}
+
+	// Блокировка, чтобы старое значение соответствовало именно этому изменению
+	d.Lock()
+	old := d.drv.Get(name)
+	d.drv.Set(name, value)
+	d.Unlock()
+
+	d.hooks.OnSet(name, old, value)
+}
+
+func (d *hookDriver) Del(name string) {
+	if d.hooks.OnDel == nil {
+		d.drv.Del(name)
+		return
+	}
+
+	d.Lock()
+	old := d.drv.Get(name)
+	d.drv.Del(name)
+	d.Unlock()
+
+	d.hooks.OnDel(name, old)
+}
+
+func (d *hookDriver) Keys(prefix string) []string {
+	if enum := enumDriver(d.drv); enum != nil {
+		return enum.Keys(prefix)
+	}
+
+	return nil
+}
+
+func (d *hookDriver) Range(fn func(name string, values []string) bool) {
+	if enum := enumDriver(d.drv); enum != nil {
+		enum.Range(fn)
+	}
+}
+
+func (d *hookDriver) LookupValue(name string) (Value, bool) {
+	typed := typedDriver(d.drv)
+
+	if typed == nil {
+		return Value{}, false
+	}
+
+	val, ok := typed.LookupValue(name)
+
+	if ok && d.hooks.OnGet != nil {
+		d.hooks.OnGet(name, []string{val.String()})
+	}
+
+	return val, ok
+}
+
+func (d *hookDriver) normalize(name string) string {
+	return normalizeKey(d.drv, name)
+}
@@ -0,0 +1,92 @@
+package envx_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/shestakovda/envx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHookDriver(t *testing.T) {
+	var log []string
+
+	drv := envx.NewHookDriver(envx.NewMemDriver(16), envx.Hooks{
+		OnGet: func(name string, values []string) { log = append(log, "get "+name+"="+strings.Join(values, ",")) },
+		OnSet: func(name, old, value string) { log = append(log, "set "+name+"="+old+">"+value) },
+		OnDel: func(name, old string) { log = append(log, "del "+name+"="+old) },
+	})
+
+	testDriver(t, drv)
+
+	assert.Equal(t, []string{
+		"get Key=", "get Key=",
+		"set Key=> Value ", "get Key=Value", "get Key=Value",
+		"del Key=Value", "get Key=", "get Key=",
+	}, log)
+}
+
+func TestProviderHooks(t *testing.T) {
+	var defs, errs []string
+
+	prv := envx.NewProvider(envx.NewMemDriver(16), envx.WithHooks(envx.Hooks{
+		OnDefault: func(name string, def interface{}) { defs = append(defs, name) },
+		OnError:   func(name string, err error) { errs = append(errs, name+": "+err.Error()) },
+	}))
+
+	assert.Equal(t, "def", prv.String("a", "def"))
+	num, err := prv.Uint64("b", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), num)
+
+	prv.Set("c", wtf)
+	_, err = prv.URL("c", "")
+	assert.True(t, errors.Is(err, envx.ErrURLInvalid))
+
+	assert.Equal(t, []string{"a", "b"}, defs)
+	assert.Equal(t, []string{"c: " + envx.ErrURLInvalid.Error()}, errs)
+}
+
+func TestAudit(t *testing.T) {
+	var recs []*envx.AuditRecord
+
+	prv := envx.NewProvider(envx.NewMemDriver(16), envx.WithHooks(envx.NewAuditHooks(envx.AuditFunc(
+		func(rec *envx.AuditRecord) { recs = append(recs, rec) },
+	))))
+
+	prv.Set("db_host", "localhost")
+	prv.Set("db_password", "qwerty")
+	prv.Set("db_password", "12345")
+	prv.Del("db_host")
+
+	if assert.Len(t, recs, 4) {
+		assert.Equal(t, envx.AuditSet, recs[0].Action)
+		assert.Equal(t, "db_host", recs[0].Name)
+		assert.Equal(t, "", recs[0].Old)
+		assert.Equal(t, "localhost", recs[0].New)
+		assert.Contains(t, recs[0].Caller, "hooks_test.go:")
+		assert.False(t, recs[0].Time.IsZero())
+
+		assert.Equal(t, "", recs[1].Old)
+		assert.Equal(t, "***", recs[1].New)
+		assert.Equal(t, "***", recs[2].Old)
+		assert.Equal(t, "***", recs[2].New)
+
+		assert.Equal(t, envx.AuditDel, recs[3].Action)
+		assert.Equal(t, "localhost", recs[3].Old)
+		assert.Equal(t, "", recs[3].New)
+	}
+
+	var buf bytes.Buffer
+
+	drv := envx.NewHookDriver(envx.NewMemDriver(16), envx.NewAuditHooks(
+		envx.NewSlogAuditSink(slog.New(slog.NewTextHandler(&buf, nil))), "pin",
+	))
+
+	drv.Set("card_pin", "1234")
+	assert.Contains(t, buf.String(), "action=set name=card_pin old=\"\" new=***")
+	assert.Contains(t, buf.String(), "caller=hooks_test.go:")
+}
@@ -24,16 +24,29 @@ type Provider interface {
 		* Методы могут как-то преобразовывать сырые значения, если это необходимо
 	*/
 	Bool(name string, def bool) bool
+	BoolStrict(name string, def bool) (bool, error)
 	String(name string, def string) string
 	URL(name string, def string) (string, error)
 	UUID(name string, def string) (string, error)
 	GUID(name string, def string) (string, error)
 	JSON(name, def string, item interface{}) error
 	Uint64(name string, def uint64) (uint64, error)
+	Bytes(name string, def uint64) (uint64, error)
 	Timezone(name string, def string) (*time.Location, error)
 	Duration(name string, def time.Duration) (time.Duration, error)
+	DurationRange(name string, def, min, max time.Duration) (time.Duration, error)
 	StringArray(name string, def []string) ([]string, error)
 	TimeRFC3339(name string, def time.Time) (time.Time, error)
+
+	/*
+		Группа методов для перечисления параметров.
+
+		* Работают, только если драйвер реализует Enumerable, иначе возвращают пустой результат
+		* Ключи возвращаются в порядке сортировки
+		* Dump записывает значения, которые уже читались через Bytes, в виде FormatBytes
+	*/
+	Keys(prefix string) []string
+	Dump(prefix string) map[string][]string
 }
 
 // Driver - реализация конкретного поставщика параметров
@@ -75,6 +88,262 @@ type Driver interface {
 	GetArray(name string) []string
 }
 
+// Enumerable - драйвер, позволяющий перечислить свои ключи
+type Enumerable interface {
+	/*
+		Keys - список ключей, начинающихся с префикса.
+
+		* Должен возвращать ключи в порядке сортировки
+		* Должен возвращать ключи в том виде, в котором их принимает Get
+		* Пустой префикс означает все ключи
+	*/
+	Keys(prefix string) []string
+
+	/*
+		Range - обход всех ключей со значениями.
+
+		* Должен обходить ключи в порядке сортировки
+		* Значения должны быть такими же, как их вернет GetArray
+		* Обход прекращается, если fn вернула false
+	*/
+	Range(fn func(name string, values []string) bool)
+}
+
+// Restorable - драйвер, умеющий сохранить и вернуть значения ключей без нормализации
+type Restorable interface {
+	/*
+		Snapshot - исходные значения ключей, без обрезки пробелов.
+
+		* Для отсутствующего ключа сохраняется nil
+	*/
+	Snapshot(names ...string) map[string][]string
+
+	/*
+		Restore - возврат значений, сохраненных Snapshot.
+
+		* Ключ со значением nil удаляется
+	*/
+	Restore(snap map[string][]string)
+}
+
+// MemDriver - драйвер, хранящий значения в памяти
+type MemDriver interface {
+	Driver
+	Enumerable
+	Restorable
+
+	/*
+		Replace - замена всех значений по ключу одним.
+	*/
+	Replace(name, value string)
+
+	/*
+		SetArray - замена всех значений по ключу списком.
+
+		* Сохраняет копию списка, последующие изменения values не влияют на драйвер
+		* Пустой список сохраняется как есть, nil удаляет ключ
+	*/
+	SetArray(name string, values []string)
+}
+
+// EnvSnapshot - драйвер, работающий с собственной копией окружения
+type EnvSnapshot interface {
+	Driver
+	Enumerable
+
+	/*
+		Environ - копия окружения в формате KEY=VALUE, в т.ч. переменные без префикса.
+
+		* Подходит для exec.Cmd.Env
+		* Переменные возвращаются в порядке сортировки
+	*/
+	Environ() []string
+}
+
+// Typed - драйвер, сохраняющий исходные типы значений
+type Typed interface {
+	/*
+		LookupValue - получение значения с исходным типом.
+
+		* Должен возвращать false, если значения нет
+		* Провайдер использует исходный тип вместо разбора строки, если драйвер это поддерживает
+	*/
+	LookupValue(name string) (Value, bool)
+}
+
+// Lookuper - драйвер, отличающий отсутствие значения от явно пустого
+type Lookuper interface {
+	/*
+		Lookup - получение первого значения по ключу.
+
+		* Должен возвращать false, если значения нет
+		* Должен возвращать пустую строку и true, если значение явно задано пустым
+		* Для драйверов без этого метода то же самое делает функция Lookup
+	*/
+	Lookup(name string) (string, bool)
+}
+
+// JSONDriver - драйвер JSON-документа
+type JSONDriver interface {
+	Driver
+	Typed
+	Enumerable
+
+	/*
+		Select - получение списка значений по пути с проверкой его корректности.
+
+		* Поддерживает `#`, `$`, `@` и `**` в любом сочетании (см. path.go)
+		* Возвращает ErrPathInvalid для некорректного пути, GetArray в этом случае возвращает nil
+		* Get и LookupValue разбирают путь так же, для путей с шаблонами возвращают первое найденное значение
+	*/
+	Select(name string) ([]string, error)
+
+	/*
+		Update - установка значения по пути с ошибкой вместо молчаливого отказа.
+
+		* Создает промежуточные объекты и массивы, если их нет
+		* Добавляет значение в конец, если по пути уже находится массив
+		* Возвращает ErrReadOnly, если драйвер только для чтения
+	*/
+	Update(name, value string) error
+
+	/*
+		Remove - удаление значения по пути с ошибкой вместо молчаливого отказа.
+
+		* Возвращает ErrReadOnly, если драйвер только для чтения
+	*/
+	Remove(name string) error
+
+	/*
+		Bytes - копия документа с учетом всех изменений.
+	*/
+	Bytes() []byte
+
+	/*
+		Sub - драйвер для объекта или массива по пути.
+
+		* Работает с копией поддерева, изменения не попадают в исходный документ
+		* Если по пути нет объекта или массива, возвращает драйвер пустого документа
+	*/
+	Sub(name string) Driver
+
+	/*
+		Each - обход элементов массива или полей объекта по пути как отдельных драйверов.
+
+		* Для объекта i - порядковый номер поля
+		* Обход прекращается, если fn вернула false
+	*/
+	Each(name string, fn func(i int, sub Driver) bool)
+}
+
+// XMLDriver - драйвер XML-документа (только для чтения)
+type XMLDriver interface {
+	Driver
+
+	/*
+		Update - всегда ErrReadOnly, Set при этом молча ничего не делает.
+	*/
+	Update(name, value string) error
+
+	/*
+		Remove - всегда ErrReadOnly, Del при этом молча ничего не делает.
+	*/
+	Remove(name string) error
+}
+
+// StreamDriver - драйвер большого JSON-документа, читаемого потоком (только для чтения)
+type StreamDriver interface {
+	Driver
+
+	/*
+		Select - получение списка значений по пути с ошибкой разбора пути или копии документа.
+
+		* Синтаксис пути и результаты те же, что и у JSONDriver
+		* Каждый запрос читает временную копию документа потоком, Get останавливается на первом совпадении
+		* Get для путей с `#`, `$`, `@` и `**` возвращает первое найденное значение
+		* Get для объектов и массивов возвращает JSON без пробелов
+	*/
+	Select(name string) ([]string, error)
+
+	/*
+		Close - закрытие и удаление временной копии документа, после него запросы не находят значений.
+	*/
+	Close() error
+
+	/*
+		Update - всегда ErrReadOnly, Set при этом молча ничего не делает.
+	*/
+	Update(name, value string) error
+
+	/*
+		Remove - всегда ErrReadOnly, Del при этом молча ничего не делает.
+	*/
+	Remove(name string) error
+}
+
+// Query - заранее разобранный путь к значениям JSON-документа
+type Query interface {
+	/*
+		String - исходный путь.
+	*/
+	String() string
+
+	/*
+		Eval - получение списка значений из документа, аналогично GetArray.
+
+		* Возвращает nil, если по пути ничего нет
+	*/
+	Eval(js []byte) []string
+
+	/*
+		AppendEval - добавление значений из документа в конец dst.
+
+		* Позволяет переиспользовать буфер результатов: q.AppendEval(buf[:0], js)
+		* Не выделяет память, кроме копий найденных строк и роста dst
+	*/
+	AppendEval(dst []string, js []byte) []string
+}
+
+// RemoteDriver - драйвер JSON-документа, периодически загружаемого по HTTP (только для чтения)
+type RemoteDriver interface {
+	Driver
+
+	/*
+		Status - текущее состояние загрузки.
+
+		* Содержит ETag и время последней удачной загрузки и последней попытки
+		* Признак Stale означает, что последняя попытка неудачна или данные давно не подтверждались
+		* Признак Cached означает, что данные восстановлены с диска при старте
+	*/
+	Status() RemoteStatus
+
+	/*
+		Refresh - внеочередная загрузка документа.
+
+		* Отправляет If-None-Match, если известен ETag
+		* При ошибке продолжает отдавать последнюю удачную копию
+	*/
+	Refresh() error
+
+	/*
+		Close - остановка периодического опроса.
+	*/
+	Close()
+}
+
+// CachedDriver - драйвер, кеширующий результаты чтения другого драйвера
+type CachedDriver interface {
+	Driver
+
+	/*
+		Invalidate - сброс кеша по ключам.
+
+		* Если ключи не переданы, сбрасывает весь кеш
+		* Set и Del сбрасывают кеш своего ключа автоматически
+	*/
+	Invalidate(names ...string)
+}
+
 // Ошибки модуля
 var (
 	ErrURLEmpty        = errx.New("Пустой URL")
@@ -90,4 +359,20 @@ var (
 	ErrRFC3339Invalid  = errx.New("Некорректная дата в формате RFC 3339")
 	ErrJSONInvalid     = errx.New("Некорректный JSON")
 	ErrHTTPInvalid     = errx.New("Некорректный HTTP-запрос")
+	ErrXMLInvalid      = errx.New("Некорректный XML")
+	ErrReadOnly        = errx.New("Драйвер доступен только для чтения")
+	ErrPathInvalid     = errx.New("Некорректный путь к значению")
+
+	ErrPropertiesInvalid = errx.New("Некорректный файл .properties")
+
+	ErrRemoteInvalid     = errx.New("Некорректный ответ сервера конфигурации")
+	ErrRemoteUnavailable = errx.New("Сервер конфигурации недоступен")
+	ErrRemoteCache       = errx.New("Ошибка сохранения копии конфигурации")
+
+	ErrStreamSpool = errx.New("Ошибка временной копии документа")
+
+	ErrBindInvalid  = errx.New("Некорректное описание привязки параметров")
+	ErrRequired     = errx.New("Отсутствует обязательный параметр")
+	ErrBoolInvalid  = errx.New("Некорректное логическое значение")
+	ErrBytesInvalid = errx.New("Некорректный размер")
 )
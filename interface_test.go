@@ -2,6 +2,7 @@ package envx_test
 
 import (
 	"errors"
+	"math"
 	"strings"
 	"testing"
 	"time"
@@ -31,11 +32,13 @@ func TestArgs(t *testing.T) {
 type ArgsSuite struct {
 	suite.Suite
 
+	drv envx.MemDriver
 	prv envx.Provider
 }
 
 func (s *ArgsSuite) SetupTest() {
-	s.prv = envx.NewProvider(envx.NewMemDriver(16))
+	s.drv = envx.NewMemDriver(16)
+	s.prv = envx.NewProvider(s.drv)
 }
 
 func (s *ArgsSuite) TestString() {
@@ -45,7 +48,7 @@ func (s *ArgsSuite) TestString() {
 
 	s.Equal(def, s.prv.String(name, def))
 
-	s.prv.Set(name, wtf)
+	s.drv.Replace(name, wtf)
 	s.Equal(wtf, s.prv.String(name, def))
 }
 
@@ -59,13 +62,57 @@ func (s *ArgsSuite) TestBool() {
 	yes := []string{"1", "t", "true", "y", "yes", "д", "да", " да "}
 
 	for i := range yes {
-		s.prv.Set(name, yes[i])
+		s.drv.Replace(name, yes[i])
 		s.True(s.prv.Bool(name, false))
-		s.prv.Set(name, no[i])
+		s.drv.Replace(name, no[i])
 		s.False(s.prv.Bool(name, true))
 	}
 }
 
+func (s *ArgsSuite) TestBoolStrict() {
+	s.prv.Del(name)
+
+	v, err := s.prv.BoolStrict(name, true)
+	s.NoError(err)
+	s.True(v)
+
+	no := []string{"0", "false", "NO", "off", "disabled", " нет ", "выкл"}
+	yes := []string{"1", "true", "Yes", "on", "enabled", " да ", "вкл"}
+
+	for i := range yes {
+		s.drv.Replace(name, yes[i])
+		v, err = s.prv.BoolStrict(name, false)
+		s.NoError(err)
+		s.True(v)
+
+		s.drv.Replace(name, no[i])
+		v, err = s.prv.BoolStrict(name, true)
+		s.NoError(err)
+		s.False(v)
+	}
+
+	s.drv.Replace(name, "ture")
+	_, err = s.prv.BoolStrict(name, true)
+	s.True(errors.Is(err, envx.ErrBoolInvalid))
+
+	// Собственный словарь заменяет стандартный
+	prv := envx.NewProvider(s.drv, envx.WithBoolWords([]string{"Ага"}, []string{"неа"}))
+
+	s.drv.Replace(name, "ага")
+	v, err = prv.BoolStrict(name, false)
+	s.NoError(err)
+	s.True(v)
+
+	s.drv.Replace(name, "yes")
+	_, err = prv.BoolStrict(name, false)
+	s.True(errors.Is(err, envx.ErrBoolInvalid))
+
+	// Исходный тип JSON
+	v, err = envx.NewProvider(envx.NewDriverJSON([]byte(`{"on": true}`))).BoolStrict("on", false)
+	s.NoError(err)
+	s.True(v)
+}
+
 func (s *ArgsSuite) TestURL() {
 	const def = "https://example.com"
 
@@ -79,13 +126,13 @@ func (s *ArgsSuite) TestURL() {
 		s.True(errors.Is(err, envx.ErrURLEmpty))
 	}
 
-	s.prv.Set(name, wtf)
+	s.drv.Replace(name, wtf)
 	if _, err = s.prv.URL(name, def); s.Error(err) {
 		s.True(errors.Is(err, envx.ErrURLInvalid))
 	}
 
 	str := def + "test"
-	s.prv.Set(name, str+"/")
+	s.drv.Replace(name, str+"/")
 	v, err = s.prv.URL(name, def)
 	s.NoError(err)
 	s.Equal(str, v)
@@ -104,13 +151,13 @@ func (s *ArgsSuite) TestUUID() {
 		s.True(errors.Is(err, envx.ErrUUIDEmpty))
 	}
 
-	s.prv.Set(name, wtf)
+	s.drv.Replace(name, wtf)
 	if _, err = s.prv.UUID(name, def); s.Error(err) {
 		s.True(errors.Is(err, envx.ErrUUIDInvalid))
 	}
 
 	str := "4321432143af43AF43af432143214321"
-	s.prv.Set(name, str)
+	s.drv.Replace(name, str)
 	v, err = s.prv.UUID(name, def)
 	s.NoError(err)
 	s.Equal(strings.ToLower(str), v)
@@ -129,13 +176,13 @@ func (s *ArgsSuite) TestGUID() {
 		s.True(errors.Is(err, envx.ErrGUIDEmpty))
 	}
 
-	s.prv.Set(name, wtf)
+	s.drv.Replace(name, wtf)
 	if _, err = s.prv.GUID(name, def); s.Error(err) {
 		s.True(errors.Is(err, envx.ErrGUIDInvalid))
 	}
 
 	str := "43214321-43af-43af-43af-432143214321"
-	s.prv.Set(name, str)
+	s.drv.Replace(name, str)
 	v, err = s.prv.GUID(name, def)
 	s.NoError(err)
 	s.Equal(strings.ToUpper(str), v)
@@ -150,22 +197,22 @@ func (s *ArgsSuite) TestUint64() {
 	s.NoError(err)
 	s.Equal(def, v)
 
-	s.prv.Set(name, wtf)
+	s.drv.Replace(name, wtf)
 	if _, err = s.prv.Uint64(name, def); s.Error(err) {
 		s.True(errors.Is(err, envx.ErrUint64Invalid))
 	}
 
-	s.prv.Set(name, "-60")
+	s.drv.Replace(name, "-60")
 	if _, err = s.prv.Uint64(name, def); s.Error(err) {
 		s.True(errors.Is(err, envx.ErrUint64Invalid))
 	}
 
-	s.prv.Set(name, "23.4")
+	s.drv.Replace(name, "23.4")
 	if _, err = s.prv.Uint64(name, def); s.Error(err) {
 		s.True(errors.Is(err, envx.ErrUint64Invalid))
 	}
 
-	s.prv.Set(name, "60")
+	s.drv.Replace(name, "60")
 	v, err = s.prv.Uint64(name, def)
 	s.NoError(err)
 	s.Equal(uint64(60), v)
@@ -184,13 +231,13 @@ func (s *ArgsSuite) TestTimezone() {
 		s.True(errors.Is(err, envx.ErrTimezoneEmpty))
 	}
 
-	s.prv.Set(name, wtf)
+	s.drv.Replace(name, wtf)
 	if _, err = s.prv.Timezone(name, ""); s.Error(err) {
 		s.True(errors.Is(err, envx.ErrTimezoneInvalid))
 	}
 
 	str := "America/New_York"
-	s.prv.Set(name, str)
+	s.drv.Replace(name, str)
 	v, err = s.prv.Timezone(name, def)
 	s.NoError(err)
 	s.Equal(str, v.String())
@@ -205,15 +252,129 @@ func (s *ArgsSuite) TestDuration() {
 	s.NoError(err)
 	s.Equal(def, v)
 
-	s.prv.Set(name, wtf)
+	s.drv.Replace(name, wtf)
 	if _, err = s.prv.Duration(name, def); s.Error(err) {
 		s.True(errors.Is(err, envx.ErrDurationInvalid))
 	}
 
-	s.prv.Set(name, "60s")
+	s.drv.Replace(name, "60s")
 	v, err = s.prv.Duration(name, def)
 	s.NoError(err)
 	s.Equal(time.Minute, v)
+
+	const day = 24 * time.Hour
+
+	valid := map[string]time.Duration{
+		"30d":          30 * day,
+		"2w":           14 * day,
+		"1d 2h":        day + 2*time.Hour,
+		"1D2H30M":      day + 150*time.Minute,
+		"1.5d":         36 * time.Hour,
+		"-1d":          -day,
+		"P1DT12H":      36 * time.Hour,
+		"p2w":          14 * day,
+		"PT0,5S":       500 * time.Millisecond,
+		"PT1H30M":      90 * time.Minute,
+		"P1W2DT3H4M5S": 9*day + 3*time.Hour + 4*time.Minute + 5*time.Second,
+		"-PT1M":        -time.Minute,
+		"1h 500ms":     time.Hour + 500*time.Millisecond,
+
+		// Предельные значения считаются точно, без потерь на дробных наносекундах
+		"106751d 23h 47m 16.854775807s":  math.MaxInt64,
+		"-106751d 23h 47m 16.854775808s": math.MinInt64,
+		"0.000000001s 1d":                day + 1,
+	}
+
+	for src, dur := range valid {
+		s.drv.Replace(name, src)
+		v, err = s.prv.Duration(name, def)
+		s.NoError(err, src)
+		s.Equal(dur, v, src)
+	}
+
+	for _, src := range []string{"P", "PT", "P1Y", "P1M", "PT1D", "P1H", "P1DT1HT1M", "1x", "d", "1..5d", "200000w",
+		"P1DT", "1d2d", "1h 1h", "1us 1µs", "PT1S1S", "1.5.5d", "106752d", "106751d 23h 47m 16.854775808s", "٣d",
+	} {
+		s.drv.Replace(name, src)
+		_, err = s.prv.Duration(name, def)
+		s.True(errors.Is(err, envx.ErrDurationInvalid), src)
+	}
+
+	s.drv.Replace(name, "30d")
+	v, err = s.prv.DurationRange(name, def, time.Hour, 90*day)
+	s.NoError(err)
+	s.Equal(30*day, v)
+
+	_, err = s.prv.DurationRange(name, def, time.Hour, 7*day)
+	s.True(errors.Is(err, envx.ErrDurationInvalid))
+
+	s.drv.Replace(name, "1m")
+	_, err = s.prv.DurationRange(name, def, time.Hour, 0)
+	s.True(errors.Is(err, envx.ErrDurationInvalid))
+
+	s.drv.Replace(name, "1000w")
+	v, err = s.prv.DurationRange(name, def, time.Hour, 0)
+	s.NoError(err)
+	s.Equal(7000*day, v)
+}
+
+func (s *ArgsSuite) TestBytes() {
+	s.prv.Del(name)
+
+	v, err := s.prv.Bytes(name, 1024)
+	s.NoError(err)
+	s.Equal(uint64(1024), v)
+
+	valid := map[string]uint64{
+		"42":        42,
+		"42b":       42,
+		"512MiB":    512 << 20,
+		"512 mib":   512 << 20,
+		"1.5GB":     1500000000,
+		"1.5gib":    3 << 29,
+		"0.5KiB":    512,
+		"10k":       10000,
+		"16EiB":     0,
+		"15EiB":     15 << 60,
+		"18.4467EB": 18446700000000000000,
+	}
+
+	for src, size := range valid {
+		s.drv.Replace(name, src)
+
+		if v, err = s.prv.Bytes(name, 0); src == "16EiB" {
+			s.True(errors.Is(err, envx.ErrBytesInvalid), src)
+			continue
+		}
+
+		s.NoError(err, src)
+		s.Equal(size, v, src)
+
+		// Запись размера разбирается обратно в то же число
+		s.drv.Replace(name, envx.FormatBytes(v))
+		v, err = s.prv.Bytes(name, 0)
+		s.NoError(err, src)
+		s.Equal(size, v, src)
+	}
+
+	for _, src := range []string{"MiB", "-1KB", "1.5XB", "1.", "1..5KB", "1e3", "1e", "1.0001KB", "0.1b", "1.5"} {
+		s.drv.Replace(name, src)
+		_, err = s.prv.Bytes(name, 0)
+		s.True(errors.Is(err, envx.ErrBytesInvalid), src)
+	}
+
+	s.Equal("512MiB", envx.FormatBytes(512<<20))
+	s.Equal("1500MB", envx.FormatBytes(1500000000))
+	s.Equal("1001", envx.FormatBytes(1001))
+	s.Equal("0", envx.FormatBytes(0))
+
+	prv := envx.NewProvider(envx.NewDriverJSON([]byte(`{"size": 4096, "name": "1024"}`)))
+	num, err := prv.Bytes("size", 0)
+	s.NoError(err)
+	s.Equal(uint64(4096), num)
+
+	// Размеры в дампе записываются так, чтобы Bytes разобрал их в то же число
+	s.Equal(map[string][]string{"name": {"1024"}, "size": {"4KiB"}}, prv.Dump(""))
 }
 
 func (s *ArgsSuite) TestRFC3339() {
@@ -225,13 +386,13 @@ func (s *ArgsSuite) TestRFC3339() {
 	s.NoError(err)
 	s.Equal(def, v)
 
-	s.prv.Set(name, wtf)
+	s.drv.Replace(name, wtf)
 	if _, err = s.prv.TimeRFC3339(name, def); s.Error(err) {
 		s.True(errors.Is(err, envx.ErrRFC3339Invalid))
 	}
 
 	now := time.Now()
-	s.prv.Set(name, now.Format(time.RFC3339))
+	s.drv.Replace(name, now.Format(time.RFC3339))
 	v, err = s.prv.TimeRFC3339(name, def)
 	s.NoError(err)
 	s.Equal(now.Unix(), v.Unix())
@@ -246,10 +407,16 @@ func (s *ArgsSuite) TestStringArray() {
 	s.NoError(err)
 	s.Equal(def, v)
 
-	s.prv.Set(name, wtf)
+	s.drv.Replace(name, wtf)
 	v, err = s.prv.StringArray(name, def)
 	s.NoError(err)
 	s.Equal([]string{wtf}, v)
+
+	s.prv.Set(name, " item ")
+	v, err = s.prv.StringArray(name, def)
+	s.NoError(err)
+	s.Equal([]string{wtf, "item"}, v)
+	s.Equal(wtf, s.prv.String(name, ""))
 }
 
 func (s *ArgsSuite) TestJSON() {
@@ -268,12 +435,12 @@ func (s *ArgsSuite) TestJSON() {
 
 	item := new(testType)
 
-	s.prv.Set(name, wtf)
+	s.drv.Replace(name, wtf)
 	if err := s.prv.JSON(name, def, item); s.Error(err) {
 		s.True(errors.Is(err, envx.ErrJSONInvalid))
 	}
 
-	s.prv.Set(name, `{"ololo": "purpur"}`)
+	s.drv.Replace(name, `{"ololo": "purpur"}`)
 	s.NoError(s.prv.JSON(name, def, item))
 	s.Equal("purpur", item.Test)
 }
@@ -0,0 +1,334 @@
+package envx
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shestakovda/errx"
+)
+
+// Lang - язык сообщений об ошибках
+type Lang string
+
+// Встроенные языки, русский - исходный язык сообщений
+const (
+	LangRU Lang = "ru"
+	LangEN Lang = "en"
+)
+
+// rxVerb - параметр шаблона в стиле fmt
+var rxVerb = regexp.MustCompile(`%[-+# 0]*[0-9]*(?:\.[0-9]+)?[a-zA-Z%]`)
+
+var catalog = struct {
+	sync.RWMutex
+	items map[Lang]map[string]string
+	tpls  map[Lang][]catalogTemplate
+}{
+	tpls: make(map[Lang][]catalogTemplate, 2),
+	items: map[Lang]map[string]string{
+		LangEN: {
+			// Ошибки
+			"Пустой URL":                           "Empty URL",
+			"Некорректный URL":                     "Invalid URL",
+			"Пустой UUID":                          "Empty UUID",
+			"Некорректный UUID":                    "Invalid UUID",
+			"Пустой GUID":                          "Empty GUID",
+			"Некорректный GUID":                    "Invalid GUID",
+			"Некорректное целое":                   "Invalid integer",
+			"Пустой часовой пояс":                  "Empty timezone",
+			"Некорректный часовой пояс":            "Invalid timezone",
+			"Некорректный промежуток времени":      "Invalid duration",
+			"Некорректная дата в формате RFC 3339": "Invalid RFC 3339 date",
+			"Некорректный JSON":                    "Invalid JSON",
+			"Некорректный HTTP-запрос":             "Invalid HTTP request",
+			"Драйвер доступен только для чтения":   "Driver is read-only",
+			"Некорректный путь к значению":         "Invalid value path",
+			"Некорректный XML":                     "Invalid XML",
+			"Некорректный файл .properties":        "Invalid .properties file",
+
+			"Некорректный ответ сервера конфигурации":   "Invalid configuration server response",
+			"Сервер конфигурации недоступен":            "Configuration server is unavailable",
+			"Ошибка сохранения копии конфигурации":      "Failed to save configuration copy",
+			"Ошибка временной копии документа":          "Temporary document copy failure",
+			"Некорректное описание привязки параметров": "Invalid parameter binding",
+			"Отсутствует обязательный параметр":         "Missing required parameter",
+			"Некорректное логическое значение":          "Invalid boolean",
+			"Некорректный размер":                       "Invalid byte size",
+
+			// Детализация
+			"Некорректное URL-кодирование":                         "Invalid URL encoding",
+			"Отсутствует тело запроса":                             "Missing request body",
+			"Слишком большое тело запроса":                         "Request body is too large",
+			"Некорректное содержимое заголовка `Content-Type`":     "Invalid `Content-Type` header",
+			"Тело запроса повреждено или сформировано некорректно": "Request body is corrupted or malformed",
+			"Документ не содержит элементов":                       "Document has no elements",
+			"Тело ответа не является корректным JSON":              "Response body is not valid JSON",
+			"Тело запроса не является корректным JSON":             "Request body is not valid JSON",
+			msgInternal: "Internal error",
+
+			// Детализация путей
+			"Пустой путь":                              "Empty path",
+			"Пустой сегмент в позиции %d":              "Empty segment at position %d",
+			"Пустой сегмент в конце пути":              "Empty segment at the end of the path",
+			"Незавершенное экранирование в конце пути": "Unfinished escape at the end of the path",
+			"Сегмент `@` допустим только в конце пути": "Segment `@` is allowed only at the end of the path",
+			"Сегмент `**` не может быть в конце пути":  "Segment `**` cannot end the path",
+			"Сегменты `**` не могут идти подряд":       "Segments `**` cannot follow each other",
+
+			// Детализация драйверов
+			"Неполная последовательность \\u в позиции %d": "Incomplete \\u sequence at position %d",
+			"Некорректная последовательность \\u%s":        "Invalid \\u%s sequence",
+			"Сервер вернул статус %d":                      "Server returned status %d",
+
+			// Детализация привязки параметров
+			"Ожидается указатель на структуру, получено %T": "Pointer to a struct expected, got %T",
+			"Поле не экспортируется":                        "Field is not exported",
+			"Ожидается `источник:имя`":                      "`source:name` expected",
+			"Неизвестная опция `%s`":                        "Unknown option `%s`",
+			"Неизвестный источник `%s`":                     "Unknown source `%s`",
+			"Значение больше допустимого для %s":            "Value exceeds the limit for %s",
+			"Тело запроса не подходит для источника `%s`":   "Request body does not match source `%s`",
+
+			// Детализация промежутков времени
+			"Пустое значение":                    "Empty value",
+			"Пустой промежуток ISO 8601":         "Empty ISO 8601 duration",
+			"Повторный разделитель `T`":          "Repeated `T` designator",
+			"После разделителя `T` нет времени":  "No time after the `T` designator",
+			"Годы и месяцы не поддерживаются":    "Years and months are not supported",
+			"Неизвестная единица `%s`":           "Unknown unit `%s`",
+			"Повторная единица `%s`":             "Repeated unit `%s`",
+			"Некорректное число `%s`":            "Invalid number `%s`",
+			"Ожидается число в начале `%s`":      "Number expected at the start of `%s`",
+			"Слишком большой промежуток":         "Duration is too large",
+			"Значение вне диапазона от %s до %s": "Value is out of range from %s to %s",
+
+			// Детализация размеров
+			"Ожидается неотрицательное число перед единицей": "Non-negative number expected before the unit",
+			"Размер должен быть целым числом байт":           "Size must be a whole number of bytes",
+			"Размер больше %d байт":                          "Size exceeds %d bytes",
+
+			// Отладка
+			argName:           "Parameter",
+			argValue:          "Value",
+			"Адрес":           "Address",
+			"Заголовки":       "Headers",
+			"Смещение":        "Offset",
+			"Строка":          "Line",
+			"Файл":            "File",
+			"Тип содержимого": "Content type",
+			argField:          "Field",
+		},
+	},
+}
+
+// RegisterCatalog - добавление или замена переводов, ключ - исходное сообщение на русском
+//
+// * Сообщения без перевода выводятся как есть
+// * Ключ детализации с параметрами - исходный шаблон, например `Пустой сегмент в позиции %d`
+// * Параметры подставляются в перевод строками в том же порядке, что и в исходном шаблоне
+func RegisterCatalog(lang Lang, items map[string]string) {
+	catalog.Lock()
+	defer catalog.Unlock()
+
+	if catalog.items[lang] == nil {
+		catalog.items[lang] = make(map[string]string, len(items))
+	}
+
+	for key := range items {
+		catalog.items[lang][key] = items[key]
+	}
+
+	delete(catalog.tpls, lang)
+}
+
+// Translate - перевод сообщения на указанный язык
+func Translate(lang Lang, text string) string {
+	catalog.RLock()
+	msg, ok := catalog.items[lang][text]
+	catalog.RUnlock()
+
+	if ok {
+		return msg
+	}
+
+	for _, tpl := range templates(lang) {
+		if args := tpl.rx.FindStringSubmatch(text); args != nil {
+			return tpl.format(args[1:])
+		}
+	}
+
+	return text
+}
+
+// catalogTemplate - шаблон детализации с параметрами, по которому узнается уже подставленный текст
+type catalogTemplate struct {
+	rx  *regexp.Regexp
+	out string
+}
+
+func (t *catalogTemplate) format(args []string) string {
+	vals := make([]interface{}, len(args))
+
+	for i := range args {
+		vals[i] = args[i]
+	}
+
+	return fmt.Sprintf(t.out, vals...)
+}
+
+// templates - шаблоны каталога, компилируются при первом обращении после изменения каталога
+func templates(lang Lang) []catalogTemplate {
+	catalog.RLock()
+	tpls, ok := catalog.tpls[lang]
+	catalog.RUnlock()
+
+	if ok {
+		return tpls
+	}
+
+	catalog.Lock()
+	defer catalog.Unlock()
+
+	if tpls, ok = catalog.tpls[lang]; ok {
+		return tpls
+	}
+
+	for key, msg := range catalog.items[lang] {
+		if tpl, ok := compileTemplate(key, msg); ok {
+			tpls = append(tpls, tpl)
+		}
+	}
+
+	// Сначала более подробные шаблоны, чтобы выбор не зависел от порядка обхода каталога
+	sort.Slice(tpls, func(i, j int) bool {
+		if a, b := len(tpls[i].rx.String()), len(tpls[j].rx.String()); a != b {
+			return a > b
+		}
+
+		return tpls[i].rx.String() < tpls[j].rx.String()
+	})
+
+	catalog.tpls[lang] = tpls
+	return tpls
+}
+
+// compileTemplate - регулярное выражение по исходному шаблону, в переводе все параметры становятся строками
+func compileTemplate(key, msg string) (tpl catalogTemplate, ok bool) {
+	var rx strings.Builder
+
+	last := 0
+	rx.WriteByte('^')
+
+	for _, pos := range rxVerb.FindAllStringIndex(key, -1) {
+		rx.WriteString(regexp.QuoteMeta(key[last:pos[0]]))
+
+		if verb := key[pos[0]:pos[1]]; verb == "%%" {
+			rx.WriteString("%")
+		} else {
+			rx.WriteString("(.+?)")
+			ok = true
+		}
+
+		last = pos[1]
+	}
+
+	if !ok {
+		return tpl, false
+	}
+
+	rx.WriteString(regexp.QuoteMeta(key[last:]))
+	rx.WriteByte('$')
+
+	tpl.rx = regexp.MustCompile(rx.String())
+	tpl.out = rxVerb.ReplaceAllStringFunc(msg, func(verb string) string {
+		if verb == "%%" {
+			return verb
+		}
+
+		return "%s"
+	})
+
+	return tpl, true
+}
+
+// Localize - ошибка, выводящая сообщения на указанном языке
+//
+// Идентичность сохраняется: errors.Is(Localize(err, LangEN), err) и errors.Is с любой ошибкой из цепочки
+func Localize(err error, lang Lang) error {
+	if loc, ok := err.(*localError); ok {
+		err = loc.err
+	}
+
+	src, ok := err.(errx.Error)
+
+	if !ok {
+		return err
+	}
+
+	return &localError{err: src, lang: lang}
+}
+
+type localError struct {
+	err  errx.Error
+	lang Lang
+}
+
+func (e *localError) Unwrap() error              { return e.err }
+func (e *localError) Is(target error) bool       { return errors.Is(e.err, target) }
+func (e *localError) Export() *errx.View         { return localView(e.err.Export(), e.lang) }
+func (e *localError) Error() string              { return Translate(e.lang, e.err.Error()) }
+func (e *localError) Format(f fmt.State, r rune) { formatView(f, r, e.Export()) }
+
+// localView - перевод всех сообщений цепочки
+func localView(v *errx.View, lang Lang) *errx.View {
+	if v == nil {
+		return nil
+	}
+
+	res := &errx.View{
+		Text:   Translate(lang, v.Text),
+		Detail: Translate(lang, v.Detail),
+		Stack:  v.Stack,
+		Next:   localView(v.Next, lang),
+	}
+
+	if v.Debug != nil {
+		res.Debug = make(map[string]string, len(v.Debug))
+
+		for key := range v.Debug {
+			res.Debug[Translate(lang, key)] = v.Debug[key]
+		}
+	}
+
+	return res
+}
+
+// formatView - вывод в том же виде, что и у errx
+func formatView(f fmt.State, r rune, v *errx.View) {
+	fmt.Fprintf(f, "> %s", v.Text)
+
+	if v.Detail != "" {
+		fmt.Fprintf(f, " (%s)", v.Detail)
+	}
+
+	if r != 'v' {
+		return
+	}
+
+	for key := range v.Debug {
+		fmt.Fprintf(f, "\n|   %s: %s", key, v.Debug[key])
+	}
+
+	if f.Flag('+') && len(v.Stack) > 0 {
+		fmt.Fprintf(f, "\n|       %s", strings.Join(v.Stack, "\n|       "))
+	}
+
+	if v.Next != nil {
+		fmt.Fprint(f, "\n|-")
+		formatView(f, r, v.Next)
+	}
+}
@@ -0,0 +1,145 @@
+package envx_test
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/shestakovda/envx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalize(t *testing.T) {
+	drv := envx.NewMemDriver(4)
+	drv.Set("port", "eighty")
+
+	var hooked error
+
+	prv := envx.NewProvider(drv, envx.WithLang(envx.LangEN), envx.WithHooks(envx.Hooks{
+		OnError: func(name string, err error) { hooked = err },
+	}))
+
+	_, err := prv.Uint64("port", 0)
+	assert.True(t, errors.Is(err, envx.ErrUint64Invalid))
+	assert.Equal(t, "Invalid integer", err.Error())
+	assert.Equal(t, envx.ErrUint64Invalid.Error(), hooked.Error())
+
+	msg := fmt.Sprintf("%v", err)
+	assert.True(t, strings.HasPrefix(msg, "> Invalid integer"))
+	assert.Contains(t, msg, "Parameter: \"port\"")
+	assert.Contains(t, msg, "|-> strconv.ParseUint")
+
+	// Русский язык по-умолчанию
+	_, err = envx.NewProvider(drv).Uint64("port", 0)
+	assert.Equal(t, envx.ErrUint64Invalid.Error(), err.Error())
+
+	// Перевод при выводе и повторный перевод
+	req, err := http.NewRequest("POST", "", nil)
+	assert.NoError(t, err)
+	_, err = envx.NewHTTPDriver(req)
+
+	loc := envx.Localize(err, envx.LangEN)
+	assert.True(t, errors.Is(loc, envx.ErrHTTPInvalid))
+	assert.Equal(t, "> Invalid HTTP request (Missing request body)", fmt.Sprintf("%s", loc))
+	assert.Equal(t, envx.ErrHTTPInvalid.Error(), envx.Localize(loc, envx.LangRU).Error())
+
+	// Собственный каталог
+	envx.RegisterCatalog("de", map[string]string{envx.ErrHTTPInvalid.Error(): "Ungültige HTTP-Anfrage"})
+	assert.Equal(t, "Ungültige HTTP-Anfrage", envx.Localize(err, "de").Error())
+	assert.Equal(t, "Empty URL", envx.Translate(envx.LangEN, envx.ErrURLEmpty.Error()))
+	assert.Equal(t, "как есть", envx.Translate(envx.LangEN, "как есть"))
+
+	// Детализация с параметрами переводится по исходному шаблону
+	_, err = envx.NewDriverJSON(nil).Select("a..b")
+	assert.Equal(t, "> Invalid value path (Empty segment at position 2)", fmt.Sprintf("%s", envx.Localize(err, envx.LangEN)))
+	assert.Equal(t, "Value is out of range from 1h0m0s to 2h0m0s", envx.Translate(envx.LangEN, "Значение вне диапазона от 1h0m0s до 2h0m0s"))
+	assert.Equal(t, "Пустой сегмент в позиции", envx.Translate(envx.LangEN, "Пустой сегмент в позиции"))
+
+	envx.RegisterCatalog("de", map[string]string{"Сервер вернул статус %d": "Server antwortete mit %d (100%%)"})
+	assert.Equal(t, "Server antwortete mit 503 (100%)", envx.Translate("de", "Сервер вернул статус 503"))
+
+	assert.Nil(t, envx.Localize(nil, envx.LangEN))
+	plain := errors.New("plain")
+	assert.Equal(t, plain, envx.Localize(plain, envx.LangEN))
+}
+
+// TestCatalogComplete - у каждого сообщения, детализации и ключа отладки в коде есть английский перевод
+func TestCatalogComplete(t *testing.T) {
+	var msgs []string
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi fs.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	assert.NoError(t, err)
+
+	// literal - текст строкового литерала или пустая строка
+	literal := func(expr ast.Expr) string {
+		if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			if s, err := strconv.Unquote(lit.Value); err == nil {
+				return s
+			}
+		}
+
+		return ""
+	}
+
+	for _, pkg := range pkgs {
+		ast.Inspect(pkg, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.CallExpr:
+				var pos int
+
+				switch fn := n.Fun.(type) {
+				case *ast.SelectorExpr:
+					if fn.Sel.Name != "New" && fn.Sel.Name != "WithDetail" {
+						return true
+					}
+				case *ast.Ident:
+					if fn.Name != "pathError" {
+						return true
+					}
+
+					pos = 1
+				default:
+					return true
+				}
+
+				if len(n.Args) > pos {
+					msgs = append(msgs, literal(n.Args[pos]))
+				}
+			case *ast.AssignStmt:
+				if id, ok := n.Lhs[0].(*ast.Ident); ok && id.Name == "detail" {
+					msgs = append(msgs, literal(n.Rhs[0]))
+				}
+			case *ast.CompositeLit:
+				if sel, ok := n.Type.(*ast.SelectorExpr); !ok || sel.Sel.Name != "Debug" {
+					return true
+				}
+
+				for _, elt := range n.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						msgs = append(msgs, literal(kv.Key))
+					}
+				}
+			}
+
+			return true
+		})
+	}
+
+	assert.NotEmpty(t, msgs)
+
+	for _, msg := range msgs {
+		if msg != "" {
+			assert.NotEqual(t, msg, envx.Translate(envx.LangEN, msg), "нет перевода")
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package envx
+
+// Lookup - получение первого значения с признаком его наличия для любого драйвера
+//
+// * Использует метод драйвера, если он реализует Lookuper
+// * Иначе значение есть, если GetArray не вернул nil, пустой список - значение, заданное пустым
+func Lookup(drv Driver, name string) (string, bool) {
+	if look, ok := drv.(Lookuper); ok {
+		return look.Lookup(name)
+	}
+
+	list := drv.GetArray(name)
+
+	if len(list) > 0 {
+		return list[0], true
+	}
+
+	return "", list != nil
+}
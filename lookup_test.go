@@ -0,0 +1,60 @@
+package envx_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shestakovda/envx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	drv := envx.NewMemDriver(4)
+	drv.Set("empty", " ")
+	drv.Set("url", "http://example.com/")
+	drv.SetArray("none", []string{})
+
+	s, ok := envx.Lookup(drv, "empty")
+	assert.True(t, ok)
+	assert.Equal(t, e, s)
+
+	_, ok = envx.Lookup(drv, "missing")
+	assert.False(t, ok)
+
+	// Адаптер для драйверов без собственного Lookup
+	hook := envx.NewHookDriver(drv, envx.Hooks{})
+	_, ok = hook.(envx.Lookuper)
+	assert.False(t, ok)
+
+	s, ok = envx.Lookup(hook, "none")
+	assert.True(t, ok)
+	assert.Equal(t, e, s)
+
+	_, ok = envx.Lookup(hook, "missing")
+	assert.False(t, ok)
+
+	// По-умолчанию пустое значение считается отсутствующим
+	prv := envx.NewProvider(drv)
+	assert.Equal(t, "def", prv.String("empty", "def"))
+
+	_, err := prv.URL("empty", "")
+	assert.True(t, errors.Is(err, envx.ErrURLEmpty))
+
+	// Явно пустое значение возвращается как есть
+	prv = envx.NewProvider(drv, envx.WithExplicitEmpty())
+	assert.Equal(t, e, prv.String("empty", "def"))
+	assert.Equal(t, "def", prv.String("missing", "def"))
+
+	for _, fn := range []func(string, string) (string, error){prv.URL, prv.UUID, prv.GUID} {
+		s, err = fn("empty", "")
+		assert.NoError(t, err)
+		assert.Equal(t, e, s)
+
+		_, err = fn("missing", "")
+		assert.Error(t, err)
+	}
+
+	s, err = prv.URL("url", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://example.com", s)
+}
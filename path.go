@@ -0,0 +1,291 @@
+package envx
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/shestakovda/errx"
+	"github.com/tidwall/gjson"
+)
+
+// Грамматика пути к значениям
+//
+// * Путь состоит из сегментов, разделенных точкой, точка внутри имени экранируется: `a\.b`
+// * `#` - все элементы массива, последним сегментом - длина массива
+// * `$` - все значения объекта (или элементы массива)
+// * `@` - имена полей объекта, допустим только последним сегментом
+// * `**` - любая глубина вложенности, не может быть последним сегментом
+// * Остальные сегменты - имена полей или индексы массивов
+const (
+	segAll   = "#"
+	segVals  = "$"
+	segKeys  = "@"
+	segDeep  = "**"
+	pathStep = '.'
+	pathEsc  = '\\'
+)
+
+type segKind uint8
+
+const (
+	kindKey segKind = iota
+	kindAll
+	kindVals
+	kindKeys
+	kindDeep
+)
+
+// segment - разобранный сегмент пути
+type segment struct {
+	kind segKind
+	key  string // имя без экранирования
+	raw  string // имя с экранированием, как его понимает gjson
+}
+
+type jsonPath []segment
+
+// parsePath - разбор пути с проверкой корректности
+func parsePath(path string) (_ jsonPath, err error) {
+	var raw []string
+
+	if raw, err = splitPath(path); err != nil {
+		return nil, err
+	}
+
+	res := make(jsonPath, len(raw))
+
+	for i := range raw {
+		switch raw[i] {
+		case segAll:
+			res[i].kind = kindAll
+		case segVals:
+			res[i].kind = kindVals
+		case segKeys:
+			if i != len(raw)-1 {
+				return nil, pathError(path, "Сегмент `@` допустим только в конце пути")
+			}
+			res[i].kind = kindKeys
+		case segDeep:
+			if i == len(raw)-1 {
+				return nil, pathError(path, "Сегмент `**` не может быть в конце пути")
+			}
+			if i > 0 && res[i-1].kind == kindDeep {
+				return nil, pathError(path, "Сегменты `**` не могут идти подряд")
+			}
+			res[i].kind = kindDeep
+		default:
+			res[i].kind = kindKey
+			res[i].raw = raw[i]
+			res[i].key = unescapeKey(raw[i])
+		}
+	}
+
+	return res, nil
+}
+
+// splitPath - деление пути на сегменты с учетом экранирования
+func splitPath(path string) ([]string, error) {
+	if path == "" {
+		return nil, pathError(path, "Пустой путь")
+	}
+
+	res := make([]string, 0, 8)
+	beg := 0
+
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case pathEsc:
+			if i++; i == len(path) {
+				return nil, pathError(path, "Незавершенное экранирование в конце пути")
+			}
+		case pathStep:
+			if i == beg {
+				return nil, pathError(path, "Пустой сегмент в позиции %d", i)
+			}
+
+			res = append(res, path[beg:i])
+			beg = i + 1
+		}
+	}
+
+	if beg == len(path) {
+		return nil, pathError(path, "Пустой сегмент в конце пути")
+	}
+
+	return append(res, path[beg:]), nil
+}
+
+func unescapeKey(key string) string {
+	if strings.IndexByte(key, pathEsc) < 0 {
+		return key
+	}
+
+	var buf strings.Builder
+
+	for i := 0; i < len(key); i++ {
+		if key[i] == pathEsc {
+			i++
+		}
+		buf.WriteByte(key[i])
+	}
+
+	return buf.String()
+}
+
+func pathError(path, tpl string, args ...interface{}) errx.Error {
+	return ErrPathInvalid.WithDetail(tpl, args...).WithDebug(errx.Debug{argName: path})
+}
+
+// eval - сбор всех значений по пути, вложенные массивы разворачиваются
+// Возвращает nil, если по пути ничего нет
+func (p jsonPath) eval(res gjson.Result, list []string) []string {
+	var found bool
+
+	list = p.walk(res, list, &found)
+
+	if !found {
+		return nil
+	}
+
+	if list == nil {
+		list = make([]string, 0)
+	}
+
+	return list
+}
+
+func (p jsonPath) walk(res gjson.Result, list []string, found *bool) []string {
+	p.visit(res, found, func(item gjson.Result) bool {
+		list = collect(item, list, found)
+		return true
+	})
+
+	return list
+}
+
+// first - первое значение по пути, массивы раскрываются так же, как в eval
+func (p jsonPath) first(res gjson.Result) (val gjson.Result, ok bool) {
+	var found bool
+
+	p.visit(res, &found, func(item gjson.Result) bool {
+		val, ok = firstItem(item)
+		return !ok
+	})
+
+	return val, ok
+}
+
+// exact - путь без шаблонов, его можно передать в gjson как есть
+func (p jsonPath) exact() (string, bool) {
+	keys := make([]string, len(p))
+
+	for i := range p {
+		if p[i].kind != kindKey {
+			return "", false
+		}
+
+		keys[i] = p[i].raw
+	}
+
+	return strings.Join(keys, "."), true
+}
+
+// visit - обход найденных по пути значений, включая длины массивов и имена полей
+// Обход прекращается, как только fn вернет false
+func (p jsonPath) visit(res gjson.Result, found *bool, fn func(gjson.Result) bool) bool {
+	if !res.Exists() {
+		return true
+	}
+
+	if len(p) == 0 {
+		return fn(res)
+	}
+
+	next := true
+
+	switch seg, rest := p[0], p[1:]; seg.kind {
+	case kindKey:
+		next = rest.visit(res.Get(seg.raw), found, fn)
+	case kindAll:
+		if !res.IsArray() {
+			break
+		}
+
+		// Существующий массив - уже результат, даже если в элементах ничего не нашлось
+		*found = true
+
+		if len(rest) == 0 {
+			return fn(gjson.Parse(strconv.Itoa(len(res.Array()))))
+		}
+
+		res.ForEach(func(_, item gjson.Result) bool {
+			next = rest.visit(item, found, fn)
+			return next
+		})
+	case kindVals:
+		if !res.IsObject() && !res.IsArray() {
+			break
+		}
+
+		*found = true
+		res.ForEach(func(_, item gjson.Result) bool {
+			next = rest.visit(item, found, fn)
+			return next
+		})
+	case kindKeys:
+		if !res.IsObject() {
+			break
+		}
+
+		*found = true
+		res.ForEach(func(key, _ gjson.Result) bool {
+			next = fn(key)
+			return next
+		})
+	case kindDeep:
+		if next = rest.visit(res, found, fn); !next {
+			break
+		}
+
+		if !res.IsObject() && !res.IsArray() {
+			break
+		}
+
+		res.ForEach(func(_, item gjson.Result) bool {
+			next = p.visit(item, found, fn)
+			return next
+		})
+	}
+
+	return next
+}
+
+// firstItem - само значение или первый элемент массива, включая вложенные
+func firstItem(res gjson.Result) (val gjson.Result, ok bool) {
+	if !res.IsArray() {
+		return res, true
+	}
+
+	res.ForEach(func(_, item gjson.Result) bool {
+		val, ok = firstItem(item)
+		return !ok
+	})
+
+	return val, ok
+}
+
+// collect - значение или все элементы массива, включая вложенные
+func collect(res gjson.Result, list []string, found *bool) []string {
+	*found = true
+
+	if !res.IsArray() {
+		return append(list, strings.Clone(res.String()))
+	}
+
+	res.ForEach(func(_, item gjson.Result) bool {
+		list = collect(item, list, found)
+		return true
+	})
+
+	return list
+}
@@ -0,0 +1,313 @@
+package envx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shestakovda/errx"
+)
+
+// ProblemType - тип ответа с описанием ошибок по RFC 7807
+const ProblemType = "application/problem+json"
+
+const (
+	codeInternal = "internal"
+	msgInternal  = "Внутренняя ошибка"
+)
+
+// Стабильные коды ошибок, не зависят от языка сообщений
+var problemCodes = map[string]string{
+	ErrURLEmpty.Error():          "url_empty",
+	ErrURLInvalid.Error():        "url_invalid",
+	ErrUUIDEmpty.Error():         "uuid_empty",
+	ErrUUIDInvalid.Error():       "uuid_invalid",
+	ErrGUIDEmpty.Error():         "guid_empty",
+	ErrGUIDInvalid.Error():       "guid_invalid",
+	ErrUint64Invalid.Error():     "uint64_invalid",
+	ErrTimezoneEmpty.Error():     "timezone_empty",
+	ErrTimezoneInvalid.Error():   "timezone_invalid",
+	ErrDurationInvalid.Error():   "duration_invalid",
+	ErrRFC3339Invalid.Error():    "rfc3339_invalid",
+	ErrJSONInvalid.Error():       "json_invalid",
+	ErrHTTPInvalid.Error():       "http_invalid",
+	ErrReadOnly.Error():          "read_only",
+	ErrPathInvalid.Error():       "path_invalid",
+	ErrXMLInvalid.Error():        "xml_invalid",
+	ErrPropertiesInvalid.Error(): "properties_invalid",
+	ErrRemoteInvalid.Error():     "remote_invalid",
+	ErrRemoteUnavailable.Error(): "remote_unavailable",
+	ErrRemoteCache.Error():       "remote_cache",
+	ErrStreamSpool.Error():       "stream_spool",
+	ErrBindInvalid.Error():       "bind_invalid",
+	ErrRequired.Error():          "required",
+	ErrBoolInvalid.Error():       "bool_invalid",
+	ErrBytesInvalid.Error():      "bytes_invalid",
+}
+
+// Problem - описание ошибок в формате RFC 7807
+type Problem struct {
+	Type   string         `json:"type"`
+	Title  string         `json:"title"`
+	Status int            `json:"status"`
+	Params []ProblemParam `json:"invalid-params,omitempty"`
+}
+
+// ProblemParam - ошибка одного параметра
+type ProblemParam struct {
+	Code   string `json:"code"`
+	Name   string `json:"name,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// ErrorCode - стабильный код ошибки, для неизвестных ошибок - internal
+func ErrorCode(err error) string {
+	for cur := err; cur != nil; cur = errors.Unwrap(cur) {
+		if x, ok := cur.(errx.Error); ok {
+			if code, ok := problemCodes[x.Error()]; ok {
+				return code
+			}
+		}
+	}
+
+	return codeInternal
+}
+
+// NewProblem - описание одной или нескольких ошибок поставщика
+//
+// * Ошибки, объединенные через errors.Join, раскрываются
+// * Если хотя бы одна ошибка неизвестна, это ошибка сервера (500), ее текст наружу не выводится
+// * Иначе это ошибка запроса (400)
+func NewProblem(lang Lang, errs ...error) *Problem {
+	p := &Problem{
+		Type:   "about:blank",
+		Status: http.StatusBadRequest,
+	}
+
+	for _, err := range expandErrors(nil, errs) {
+		item := ProblemParam{
+			Code: ErrorCode(err),
+		}
+
+		if item.Code == codeInternal {
+			item.Reason = Translate(lang, msgInternal)
+			p.Status = http.StatusInternalServerError
+		} else {
+			item.Name, item.Reason = problemReason(err, lang)
+		}
+
+		p.Params = append(p.Params, item)
+	}
+
+	p.Title = http.StatusText(p.Status)
+	return p
+}
+
+// WriteProblem - ответ с описанием ошибок, язык выбирается по заголовку `Accept-Language`
+func WriteProblem(w http.ResponseWriter, r *http.Request, errs ...error) {
+	lang := RequestLang(r)
+	p := NewProblem(lang, errs...)
+
+	w.Header().Set("Content-Type", ProblemType)
+	w.Header().Set("Content-Language", string(lang))
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// ProblemHandler - обработчик, ошибка которого превращается в ответ по RFC 7807
+//
+// * Если обработчик уже начал ответ, ошибка не пишется, чтобы не отправлять заголовки второй раз
+type ProblemHandler func(w http.ResponseWriter, r *http.Request) error
+
+func (h ProblemHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	pw := &problemWriter{ResponseWriter: w}
+
+	if err := h(pw, r); err != nil && !pw.wrote {
+		WriteProblem(w, r, err)
+	}
+}
+
+// ProblemMiddleware - ответ по RFC 7807 на ошибки, переданные обработчиком через ReportProblem
+//
+// * Ответ пишется после обработчика, если тот сообщил об ошибках и сам ничего не ответил
+// * Паники не перехватываются
+func ProblemMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sink := new(problemSink)
+		pw := &problemWriter{ResponseWriter: w}
+
+		next.ServeHTTP(pw, r.WithContext(context.WithValue(r.Context(), problemKey{}, sink)))
+
+		if errs := sink.list(); len(errs) > 0 && !pw.wrote {
+			WriteProblem(w, r, errs...)
+		}
+	})
+}
+
+// ReportProblem - передача ошибок в ProblemMiddleware, пустые ошибки пропускаются
+// Возвращает false, если запрос прошел не через ProblemMiddleware
+func ReportProblem(r *http.Request, errs ...error) bool {
+	sink, ok := r.Context().Value(problemKey{}).(*problemSink)
+
+	if !ok {
+		return false
+	}
+
+	sink.add(errs)
+	return true
+}
+
+type problemKey struct{}
+
+// problemSink - ошибки одного запроса, обработчик может сообщать о них из нескольких горутин
+type problemSink struct {
+	sync.Mutex
+	errs []error
+}
+
+func (s *problemSink) add(errs []error) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, err := range errs {
+		if err != nil {
+			s.errs = append(s.errs, err)
+		}
+	}
+}
+
+func (s *problemSink) list() []error {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.errs
+}
+
+// problemWriter - отметка о начале ответа, после которого описание ошибок уже не отправить
+type problemWriter struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+func (w *problemWriter) WriteHeader(code int) {
+	w.wrote = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *problemWriter) Write(p []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap - доступ к исходному ответу для http.ResponseController
+func (w *problemWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// RequestLang - язык из заголовка `Accept-Language` с наибольшим весом, для которого есть каталог, иначе русский
+//
+// * При равном весе выбирается язык, указанный раньше
+// * Языки с весом `q=0` не выбираются
+func RequestLang(r *http.Request) Lang {
+	type weighted struct {
+		lang Lang
+		q    float64
+	}
+
+	var list []weighted
+
+	for _, tag := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		item := weighted{q: 1}
+
+		if pos := strings.IndexByte(tag, ';'); pos >= 0 {
+			item.q = languageWeight(tag[pos+1:])
+			tag = tag[:pos]
+		}
+
+		if pos := strings.IndexByte(tag, '-'); pos >= 0 {
+			tag = tag[:pos]
+		}
+
+		if item.lang = Lang(strings.ToLower(strings.TrimSpace(tag))); item.q > 0 {
+			list = append(list, item)
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool { return list[i].q > list[j].q })
+
+	for i := range list {
+		if lang := list[i].lang; lang == LangRU || hasCatalog(lang) {
+			return lang
+		}
+	}
+
+	return LangRU
+}
+
+// languageWeight - вес из параметров языка вида `q=0.8`, некорректный вес считается нулевым
+func languageWeight(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		if key, val, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.TrimSpace(key) == "q" {
+			if q, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil && q >= 0 && q <= 1 {
+				return q
+			}
+
+			return 0
+		}
+	}
+
+	return 1
+}
+
+func hasCatalog(lang Lang) bool {
+	catalog.RLock()
+	defer catalog.RUnlock()
+
+	return catalog.items[lang] != nil
+}
+
+func expandErrors(res, errs []error) []error {
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			res = expandErrors(res, multi.Unwrap())
+		} else {
+			res = append(res, err)
+		}
+	}
+
+	return res
+}
+
+// problemReason - имя параметра из отладочной информации и переведенное сообщение
+func problemReason(err error, lang Lang) (name, reason string) {
+	var src errx.Error
+
+	if !errors.As(err, &src) {
+		return "", err.Error()
+	}
+
+	view := localView(src.Export(), lang)
+	reason = view.Text
+
+	if view.Detail != "" {
+		reason += " (" + view.Detail + ")"
+	}
+
+	// Имя параметра сохранено в отладке в виде строки Go
+	for ; view != nil && name == ""; view = view.Next {
+		if raw, ok := view.Debug[Translate(lang, argName)]; ok {
+			if name, err = strconv.Unquote(raw); err != nil {
+				name = raw
+			}
+		}
+	}
+
+	return name, reason
+}
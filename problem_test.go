@@ -0,0 +1,150 @@
+package envx_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shestakovda/envx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblem(t *testing.T) {
+	h := envx.ProblemHandler(func(w http.ResponseWriter, r *http.Request) error {
+		drv, err := envx.NewHTTPDriver(r)
+
+		if err != nil {
+			return err
+		}
+
+		prv := envx.NewProvider(drv)
+		_, errPort := prv.Uint64("port", 0)
+		_, errID := prv.UUID("id", "")
+
+		if err = errors.Join(errPort, errID); err != nil {
+			return err
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+
+	req := httptest.NewRequest("GET", "/?port=eighty", nil)
+	req.Header.Set("Accept-Language", "fr-CH, en;q=0.9, ru;q=0.8")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var p envx.Problem
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, envx.ProblemType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, "en", rec.Header().Get("Content-Language"))
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, envx.Problem{
+		Type:   "about:blank",
+		Title:  "Bad Request",
+		Status: http.StatusBadRequest,
+		Params: []envx.ProblemParam{
+			{Code: "uint64_invalid", Name: "port", Reason: "Invalid integer"},
+			{Code: "uuid_empty", Name: "id", Reason: "Empty UUID"},
+		},
+	}, p)
+
+	req = httptest.NewRequest("GET", "/?port=80&id=0123456789abcdef0123456789abcdef", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	// Детализация переводится, неизвестные ошибки не раскрываются
+	req, err := http.NewRequest("POST", "", nil)
+	assert.NoError(t, err)
+	_, err = envx.NewHTTPDriver(req)
+
+	p = *envx.NewProblem(envx.LangRU, err, errors.New("db is down"))
+	assert.Equal(t, http.StatusInternalServerError, p.Status)
+	assert.Equal(t, []envx.ProblemParam{
+		{Code: "http_invalid", Reason: "Некорректный HTTP-запрос (Отсутствует тело запроса)"},
+		{Code: "internal", Reason: "Внутренняя ошибка"},
+	}, p.Params)
+
+	assert.Equal(t, "duration_invalid", envx.ErrorCode(envx.Localize(envx.ErrDurationInvalid.WithDetail("x"), envx.LangEN)))
+	assert.Equal(t, envx.LangRU, envx.RequestLang(httptest.NewRequest("GET", "/", nil)))
+
+	// Язык выбирается по весу, а не по порядку
+	for header, lang := range map[string]envx.Lang{
+		"ru;q=0.1, en":            envx.LangEN,
+		"en;q=0.5, ru;q=0.5":      envx.LangEN,
+		"en;q=0, fr":              envx.LangRU,
+		"fr, en-US;q=0.7, ru;q=x": envx.LangEN,
+		"ru, en;q=1":              envx.LangRU,
+	} {
+		req = httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Language", header)
+		assert.Equal(t, lang, envx.RequestLang(req), header)
+	}
+
+	// У каждой ошибки модуля есть стабильный код
+	for _, err := range []error{
+		envx.ErrURLEmpty, envx.ErrURLInvalid, envx.ErrUUIDEmpty, envx.ErrUUIDInvalid, envx.ErrGUIDEmpty,
+		envx.ErrGUIDInvalid, envx.ErrUint64Invalid, envx.ErrTimezoneEmpty, envx.ErrTimezoneInvalid,
+		envx.ErrDurationInvalid, envx.ErrRFC3339Invalid, envx.ErrJSONInvalid, envx.ErrHTTPInvalid,
+		envx.ErrXMLInvalid, envx.ErrReadOnly, envx.ErrPathInvalid, envx.ErrPropertiesInvalid,
+		envx.ErrRemoteInvalid, envx.ErrRemoteUnavailable, envx.ErrRemoteCache, envx.ErrBindInvalid,
+		envx.ErrStreamSpool, envx.ErrRequired, envx.ErrBoolInvalid, envx.ErrBytesInvalid,
+	} {
+		assert.NotEqual(t, "internal", envx.ErrorCode(err), err.Error())
+	}
+}
+
+func TestProblemMiddleware(t *testing.T) {
+	h := envx.ProblemMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("fail") {
+		case "envx":
+			envx.ReportProblem(r, envx.ErrRequired.WithDebug(map[string]interface{}{"Параметр": "port"}), nil)
+			return
+		case "late":
+			// Ответ уже начат, второй раз заголовки не пишутся
+			w.WriteHeader(http.StatusAccepted)
+			envx.ReportProblem(r, envx.ErrRequired)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	var p envx.Problem
+
+	req := httptest.NewRequest("GET", "/?fail=envx", nil)
+	req.Header.Set("Accept-Language", "en")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, envx.ProblemType, rec.Header().Get("Content-Type"))
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, []envx.ProblemParam{{Code: "required", Name: "port", Reason: "Missing required parameter"}}, p.Params)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/?fail=late", nil))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Empty(t, rec.Header().Get("Content-Type"))
+
+	// Без ProblemMiddleware сообщить об ошибке некуда
+	assert.False(t, envx.ReportProblem(httptest.NewRequest("GET", "/", nil), envx.ErrRequired))
+
+	// ProblemHandler тоже не пишет ошибку поверх начатого ответа
+	late := envx.ProblemHandler(func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusAccepted)
+		return envx.ErrRequired
+	})
+
+	rec = httptest.NewRecorder()
+	late.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/asaskevich/govalidator"
@@ -16,25 +17,133 @@ const (
 	argValue = "Значение"
 )
 
+// Словарь BoolStrict по-умолчанию, `on` - значение отмеченного HTML-флажка
+var defBoolWords = boolVocabulary(
+	[]string{"1", "t", "true", "y", "yes", "on", "enable", "enabled", "д", "да", "вкл"},
+	[]string{"0", "f", "false", "n", "no", "off", "disable", "disabled", "н", "нет", "выкл"},
+)
+
+func boolVocabulary(yes, no []string) map[string]bool {
+	words := make(map[string]bool, len(yes)+len(no))
+
+	for i := range yes {
+		words[strings.ToLower(strings.TrimSpace(yes[i]))] = true
+	}
+
+	for i := range no {
+		words[strings.ToLower(strings.TrimSpace(no[i]))] = false
+	}
+
+	return words
+}
+
+// Option - настройка поставщика параметров
+type Option func(p *provider)
+
+// WithHooks - вызов обработчиков при обращениях к драйверу, подстановке значений по-умолчанию и ошибках
+func WithHooks(hooks Hooks) Option {
+	return func(p *provider) {
+		p.hooks = hooks
+		p.Driver = NewHookDriver(p.Driver, hooks)
+	}
+}
+
+// WithLang - язык сообщений в возвращаемых ошибках, обработчики OnError получают исходные ошибки
+func WithLang(lang Lang) Option {
+	return func(p *provider) {
+		p.lang = lang
+	}
+}
+
+// WithExplicitEmpty - явно пустое значение не заменяется значением по-умолчанию
+//
+// * String, URL, UUID и GUID возвращают пустую строку без ошибки, если значение задано пустым
+// * Значение по-умолчанию и ошибки отсутствия остаются только для ключей, которых нет в драйвере
+// * Остальные методы по-прежнему считают пустое значение отсутствующим
+func WithExplicitEmpty() Option {
+	return func(p *provider) {
+		p.empty = true
+	}
+}
+
+// WithBoolWords - замена словаря BoolStrict, слова сравниваются без учета регистра
+func WithBoolWords(yes, no []string) Option {
+	return func(p *provider) {
+		p.words = boolVocabulary(yes, no)
+	}
+}
+
 // NewProvider - конструктор поставщика настроек из окружения
-func NewProvider(driver Driver) Provider {
-	return &provider{
+func NewProvider(driver Driver, opts ...Option) Provider {
+	p := &provider{
 		Driver: driver,
+		words:  defBoolWords,
 		rxUUID: regexp.MustCompile(`^[0-9a-f]{32}$`),
 		rxGUID: regexp.MustCompile(`^[0-9A-F]{8}-[0-9A-F]{4}-[0-9A-F]{4}-[0-9A-F]{4}-[0-9A-F]{12}$`),
 	}
+
+	for i := range opts {
+		opts[i](p)
+	}
+
+	return p
 }
 
 type provider struct {
 	Driver
+	sizes  sync.Map // ключи, прочитанные через Bytes, Dump записывает их через FormatBytes
+	hooks  Hooks
+	lang   Lang
+	empty  bool
+	words  map[string]bool
 	rxUUID *regexp.Regexp
 	rxGUID *regexp.Regexp
 }
 
-func (p *provider) String(name string, def string) string {
+// value - значение с исходным типом, если драйвер это поддерживает
+func (p *provider) value(name string) (Value, bool) {
+	if typed := typedDriver(p.Driver); typed != nil {
+		return typed.LookupValue(name)
+	}
+
+	return Value{}, false
+}
+
+// lookup - значение с признаком наличия, без WithExplicitEmpty пустое значение считается отсутствующим
+func (p *provider) lookup(name string) (string, bool) {
+	if p.empty {
+		return Lookup(p.Driver, name)
+	}
+
 	s := p.Get(name)
+	return s, s != ""
+}
 
-	if s == "" {
+// def - уведомление о подстановке значения по-умолчанию
+func (p *provider) def(name string, def interface{}) {
+	if p.hooks.OnDefault != nil {
+		p.hooks.OnDefault(name, def)
+	}
+}
+
+// fail - уведомление об ошибке получения значения
+func (p *provider) fail(name string, err errx.Error) error {
+	if p.hooks.OnError != nil {
+		p.hooks.OnError(name, err)
+	}
+
+	if p.lang != "" && p.lang != LangRU {
+		return Localize(err, p.lang)
+	}
+
+	return err
+}
+
+func (p *provider) String(name string, def string) string {
+	s, ok := p.lookup(name)
+
+	if !ok {
+		p.def(name, def)
 		return def
 	}
 
@@ -44,62 +153,101 @@ func (p *provider) String(name string, def string) string {
 func (p *provider) Bool(name string, def bool) bool {
 	const t1, t2, t3, t4, t5, t6, t7 = "1", "t", "true", "y", "yes", "д", "да"
 
+	if v, ok := p.value(name); ok && (v.Kind == KindBool || v.Kind == KindNumber) {
+		return v.Bool()
+	}
+
 	if s := strings.ToLower(p.Get(name)); s != "" {
 		return s == t1 || s == t2 || s == t3 || s == t4 || s == t5 || s == t6 || s == t7
 	}
 
+	p.def(name, def)
 	return def
 }
 
-func (p *provider) URL(name string, def string) (string, error) {
-	s := p.Get(name)
+func (p *provider) BoolStrict(name string, def bool) (bool, error) {
+	if v, ok := p.value(name); ok && v.Kind == KindBool {
+		return v.Bool(), nil
+	}
+
+	s := strings.ToLower(p.Get(name))
 
 	if s == "" {
+		p.def(name, def)
+		return def, nil
+	}
+
+	if val, ok := p.words[s]; ok {
+		return val, nil
+	}
+
+	return false, p.fail(name, ErrBoolInvalid.WithDebug(errx.Debug{argValue: s, argName: name}))
+}
+
+func (p *provider) URL(name string, def string) (string, error) {
+	s, ok := p.lookup(name)
+
+	if !ok {
 		if def == "" {
-			return "", ErrURLEmpty.WithDebug(errx.Debug{argName: name})
+			return "", p.fail(name, ErrURLEmpty.WithDebug(errx.Debug{argName: name}))
 		}
 
+		p.def(name, def)
 		return strings.TrimSuffix(def, "/"), nil
 	}
 
+	if s == "" {
+		return "", nil
+	}
+
 	if !govalidator.IsURL(s) {
-		return "", ErrURLInvalid.WithDebug(errx.Debug{argValue: s, argName: name})
+		return "", p.fail(name, ErrURLInvalid.WithDebug(errx.Debug{argValue: s, argName: name}))
 	}
 
 	return strings.TrimSuffix(s, "/"), nil
 }
 
 func (p *provider) UUID(name string, def string) (string, error) {
-	s := strings.ToLower(p.Get(name))
+	s, ok := p.lookup(name)
 
-	if s == "" {
+	if !ok {
 		if def == "" {
-			return "", ErrUUIDEmpty.WithDebug(errx.Debug{argName: name})
+			return "", p.fail(name, ErrUUIDEmpty.WithDebug(errx.Debug{argName: name}))
 		}
 
+		p.def(name, def)
 		return strings.ToLower(def), nil
 	}
 
+	if s = strings.ToLower(s); s == "" {
+		return "", nil
+	}
+
 	if !p.rxUUID.MatchString(s) {
-		return "", ErrUUIDInvalid.WithDebug(errx.Debug{argValue: s, argName: name})
+		return "", p.fail(name, ErrUUIDInvalid.WithDebug(errx.Debug{argValue: s, argName: name}))
 	}
 
 	return s, nil
 }
 
 func (p *provider) GUID(name string, def string) (string, error) {
-	s := strings.ToUpper(p.Get(name))
+	s, ok := p.lookup(name)
 
-	if s == "" {
+	if !ok {
 		if def == "" {
-			return "", ErrGUIDEmpty.WithDebug(errx.Debug{argName: name})
+			return "", p.fail(name, ErrGUIDEmpty.WithDebug(errx.Debug{argName: name}))
 		}
 
+		p.def(name, def)
 		return strings.ToUpper(def), nil
 	}
 
+	if s = strings.ToUpper(s); s == "" {
+		return "", nil
+	}
+
 	if !p.rxGUID.MatchString(s) {
-		return "", ErrGUIDInvalid.WithDebug(errx.Debug{argValue: s, argName: name})
+		return "", p.fail(name, ErrGUIDInvalid.WithDebug(errx.Debug{argValue: s, argName: name}))
 	}
 
 	return s, nil
@@ -109,19 +257,56 @@ func (p *provider) Uint64(name string, def uint64) (uint64, error) {
 	var err error
 	var num uint64
 
+	// Число берется из исходного представления, чтобы не терять точность
+	if v, ok := p.value(name); ok && v.Kind == KindNumber {
+		if num, ok = v.Uint(); !ok {
+			return 0, p.fail(name, ErrUint64Invalid.WithDebug(errx.Debug{argValue: v.Raw, argName: name}))
+		}
+
+		return num, nil
+	}
+
 	s := p.Get(name)
 
 	if s == "" {
+		p.def(name, def)
 		return def, nil
 	}
 
 	if num, err = strconv.ParseUint(s, 10, 64); err != nil {
-		return 0, ErrUint64Invalid.WithReason(err).WithDebug(errx.Debug{argValue: s, argName: name})
+		return 0, p.fail(name, ErrUint64Invalid.WithReason(err).WithDebug(errx.Debug{argValue: s, argName: name}))
 	}
 
 	return num, nil
 }
 
+func (p *provider) Bytes(name string, def uint64) (uint64, error) {
+	p.sizes.Store(normalizeKey(p.Driver, name), true)
+
+	if v, ok := p.value(name); ok && v.Kind == KindNumber {
+		if num, ok := v.Uint(); ok {
+			return num, nil
+		}
+
+		return 0, p.fail(name, ErrBytesInvalid.WithDebug(errx.Debug{argValue: v.Raw, argName: name}))
+	}
+
+	s := p.Get(name)
+
+	if s == "" {
+		p.def(name, def)
+		return def, nil
+	}
+
+	size, err := parseSize(s)
+
+	if err != nil {
+		return 0, p.fail(name, err.WithDebug(errx.Debug{argValue: s, argName: name}))
+	}
+
+	return size, nil
+}
+
 func (p *provider) Timezone(name string, def string) (*time.Location, error) {
 	var err error
 	var loc *time.Location
@@ -130,30 +315,47 @@ func (p *provider) Timezone(name string, def string) (*time.Location, error) {
 
 	if s == "" {
 		if def == "" {
-			return nil, ErrTimezoneEmpty.WithDebug(errx.Debug{argName: name})
+			return nil, p.fail(name, ErrTimezoneEmpty.WithDebug(errx.Debug{argName: name}))
 		}
+
+		p.def(name, def)
 		s = def
 	}
 
 	if loc, err = time.LoadLocation(s); err != nil {
-		return nil, ErrTimezoneInvalid.WithReason(err).WithDebug(errx.Debug{argValue: s, argName: name})
+		return nil, p.fail(name, ErrTimezoneInvalid.WithReason(err).WithDebug(errx.Debug{argValue: s, argName: name}))
 	}
 
 	return loc, nil
 }
 
 func (p *provider) Duration(name string, def time.Duration) (time.Duration, error) {
-	var err error
+	var err errx.Error
 	var dur time.Duration
 
 	s := strings.ToLower(p.Get(name))
 
 	if s == "" {
+		p.def(name, def)
 		return def, nil
 	}
 
-	if dur, err = time.ParseDuration(s); err != nil {
-		return 0, ErrDurationInvalid.WithReason(err).WithDebug(errx.Debug{argValue: s, argName: name})
+	if dur, err = parseDuration(s); err != nil {
+		return 0, p.fail(name, err.WithDebug(errx.Debug{argValue: s, argName: name}))
+	}
+
+	return dur, nil
+}
+
+func (p *provider) DurationRange(name string, def, min, max time.Duration) (time.Duration, error) {
+	dur, err := p.Duration(name, def)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if err := durationRange(name, dur, min, max); err != nil {
+		return 0, p.fail(name, err)
 	}
 
 	return dur, nil
@@ -166,11 +368,12 @@ func (p *provider) TimeRFC3339(name string, def time.Time) (time.Time, error) {
 	s := strings.ToUpper(p.Get(name))
 
 	if s == "" {
+		p.def(name, def)
 		return def, nil
 	}
 
 	if rfc, err = time.Parse(time.RFC3339, s); err != nil {
-		return rfc, ErrRFC3339Invalid.WithReason(err).WithDebug(errx.Debug{argValue: s, argName: name})
+		return rfc, p.fail(name, ErrRFC3339Invalid.WithReason(err).WithDebug(errx.Debug{argValue: s, argName: name}))
 	}
 
 	return rfc, nil
@@ -181,21 +384,78 @@ func (p *provider) StringArray(name string, def []string) ([]string, error) {
 		return s, nil
 	}
 
+	p.def(name, def)
 	return def, nil
 }
 
 func (p *provider) JSON(name, def string, item interface{}) error {
 	var js []byte
 
-	if s := p.Get(name); s != "" {
+	// Объекты, массивы и простые значения разбираются напрямую, строки - как JSON внутри строки
+	if v, ok := p.value(name); ok && v.Kind != KindString && v.Kind != KindNull {
+		js = []byte(v.Raw)
+	} else if s := p.Get(name); s != "" {
 		js = []byte(s)
 	} else {
+		p.def(name, def)
 		js = []byte(def)
 	}
 
 	if err := json.Unmarshal(js, item); err != nil {
-		return ErrJSONInvalid.WithReason(err).WithDebug(errx.Debug{argValue: js, argName: name})
+		return p.fail(name, ErrJSONInvalid.WithReason(err).WithDebug(errx.Debug{argValue: js, argName: name}))
 	}
 
 	return nil
 }
+
+func (p *provider) Keys(prefix string) []string {
+	if enum := enumDriver(p.Driver); enum != nil {
+		return enum.Keys(prefix)
+	}
+
+	return nil
+}
+
+func (p *provider) Dump(prefix string) map[string][]string {
+	enum := enumDriver(p.Driver)
+
+	if enum == nil {
+		return nil
+	}
+
+	// Префикс нормализует сам драйвер, поэтому ключи берутся из Keys, а значения - из Range
+	keys := enum.Keys(prefix)
+	dump := make(map[string][]string, len(keys))
+
+	for i := range keys {
+		dump[keys[i]] = nil
+	}
+
+	enum.Range(func(name string, values []string) bool {
+		if _, ok := dump[name]; ok {
+			dump[name] = p.dumpValues(name, values)
+		}
+		return true
+	})
+
+	return dump
+}
+
+// dumpValues - размеры записываются так же, как их выводит FormatBytes, остальные значения - как есть
+func (p *provider) dumpValues(name string, values []string) []string {
+	if _, ok := p.sizes.Load(name); !ok {
+		return values
+	}
+
+	res := make([]string, len(values))
+
+	for i := range values {
+		if size, err := parseSize(values[i]); err == nil {
+			res[i] = FormatBytes(size)
+		} else {
+			res[i] = values[i]
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,30 @@
+package envx
+
+// CompileQuery - разбор пути один раз для многократного применения к разным документам
+func CompileQuery(path string) (_ Query, err error) {
+	q := &query{
+		src: path,
+	}
+
+	if q.path, err = parsePath(path); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+type query struct {
+	src  string
+	path jsonPath
+}
+
+func (q *query) String() string { return q.src }
+
+func (q *query) Eval(js []byte) []string {
+	return q.path.eval(parseBytes(js), nil)
+}
+
+func (q *query) AppendEval(dst []string, js []byte) []string {
+	var found bool
+	return q.path.walk(parseBytes(js), dst, &found)
+}
@@ -0,0 +1,66 @@
+package envx_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shestakovda/envx"
+	"github.com/stretchr/testify/assert"
+)
+
+const queryBench = "Документы.#.Подписи.#.ИдФайла"
+
+func TestQuery(t *testing.T) {
+	js := []byte(jsBenchEvent)
+	drv := envx.NewDriverJSON(js)
+
+	for _, path := range []string{queryBench, "Документы.#.КодДокумента", "**.Присоединенная", "Документы.0.@", "missing"} {
+		q, err := envx.CompileQuery(path)
+		assert.NoError(t, err)
+		assert.Equal(t, path, q.String())
+		assert.Equal(t, drv.GetArray(path), q.Eval(js), path)
+	}
+
+	q, err := envx.CompileQuery(queryBench)
+	assert.NoError(t, err)
+
+	buf := make([]string, 0, 8)
+	buf = q.AppendEval(buf[:0], js)
+	buf = q.AppendEval(buf, []byte(`{"Документы": [{"Подписи": [{"ИдФайла": "ololo"}]}]}`))
+	assert.Len(t, buf, 4)
+	assert.Equal(t, "ololo", buf[3])
+
+	_, err = envx.CompileQuery("a..b")
+	assert.True(t, errors.Is(err, envx.ErrPathInvalid))
+
+	// Скомпилированный запрос с буфером выделяет память только под найденные строки
+	drvAllocs := testing.AllocsPerRun(100, func() { drv.GetArray(queryBench) })
+	queryAllocs := testing.AllocsPerRun(100, func() { buf = q.AppendEval(buf[:0], js) })
+	assert.Less(t, queryAllocs, drvAllocs)
+	assert.LessOrEqual(t, queryAllocs, float64(3))
+}
+
+func BenchmarkQueryEval(b *testing.B) {
+	js := []byte(jsBenchEvent)
+	q, err := envx.CompileQuery(queryBench)
+	assert.NoError(b, err)
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		q.Eval(js)
+	}
+}
+
+func BenchmarkQueryAppendEval(b *testing.B) {
+	js := []byte(jsBenchEvent)
+	q, err := envx.CompileQuery(queryBench)
+	assert.NoError(b, err)
+
+	buf := make([]string, 0, 8)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		buf = q.AppendEval(buf[:0], js)
+	}
+}
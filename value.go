@@ -0,0 +1,87 @@
+package envx
+
+import (
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+// Kind - исходный тип значения
+type Kind uint8
+
+// Типы значений
+const (
+	KindNull Kind = iota
+	KindBool
+	KindNumber
+	KindString
+	KindArray
+	KindObject
+)
+
+// Value - значение с сохранением исходного типа
+type Value struct {
+	// Kind - исходный тип
+	Kind Kind
+
+	// Raw - исходное представление в формате JSON
+	Raw string
+}
+
+// newValue - значение из результата поиска в JSON-документе
+func newValue(res gjson.Result) Value {
+	v := Value{Raw: res.Raw}
+
+	switch {
+	case res.IsObject():
+		v.Kind = KindObject
+	case res.IsArray():
+		v.Kind = KindArray
+	case res.Type == gjson.True, res.Type == gjson.False:
+		v.Kind = KindBool
+	case res.Type == gjson.Number:
+		v.Kind = KindNumber
+	case res.Type == gjson.String:
+		v.Kind = KindString
+	}
+
+	return v
+}
+
+// String - строковое представление, как его вернет Get
+func (v Value) String() string { return gjson.Parse(v.Raw).String() }
+
+// Bool - логическое значение, для чисел - признак неравенства нулю
+func (v Value) Bool() bool { return gjson.Parse(v.Raw).Bool() }
+
+// Float - значение с плавающей точкой
+func (v Value) Float() float64 { return gjson.Parse(v.Raw).Float() }
+
+// Int - целое значение без потери точности, false - если это не целое число
+func (v Value) Int() (int64, bool) {
+	if v.Kind != KindNumber {
+		return 0, false
+	}
+
+	num, err := strconv.ParseInt(v.Raw, 10, 64)
+	return num, err == nil
+}
+
+// Uint - беззнаковое целое значение без потери точности, false - если это не беззнаковое целое число
+func (v Value) Uint() (uint64, bool) {
+	if v.Kind != KindNumber {
+		return 0, false
+	}
+
+	num, err := strconv.ParseUint(v.Raw, 10, 64)
+	return num, err == nil
+}
+
+// typedDriver - доступ к типизированным значениям драйвера, если он это поддерживает
+func typedDriver(drv Driver) Typed {
+	if typed, ok := drv.(Typed); ok {
+		return typed
+	}
+
+	return nil
+}